@@ -0,0 +1,43 @@
+package cache
+
+import "encoding/json"
+
+// Codec serializes cache values. Caches that use one store the encoded bytes
+// and decode a fresh copy on every read, so a value returned by Get can be
+// modified without affecting the cached entry.
+type Codec interface {
+	Encode(value interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// TypedCodec is a Codec that can also decode into a value of a given type.
+// An LRUCache with a TypedCodec remembers the type each value was stored
+// with and hands back that type, so TypedCache works over it.
+type TypedCodec interface {
+	Codec
+	DecodeInto(data []byte, target interface{}) error
+}
+
+// JSONCodec encodes values as JSON. Decode returns generic JSON types
+// (map[string]interface{}, []interface{}, float64, string, bool), while
+// DecodeInto fills the given target.
+type JSONCodec struct{}
+
+// Encode marshals value to JSON
+func (JSONCodec) Encode(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+// Decode unmarshals JSON data into generic JSON types
+func (JSONCodec) Decode(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// DecodeInto unmarshals JSON data into target, a pointer
+func (JSONCodec) DecodeInto(data []byte, target interface{}) error {
+	return json.Unmarshal(data, target)
+}
@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// ttlJitterKey holds the per-call jitter set by WithTTLJitter
+type ttlJitterKey struct{}
+
+// WithTTLJitter overrides the cache's TTLJitter for the calls made with the
+// returned context, e.g. WithTTLJitter(ctx, 0) for a key that must expire
+// exactly on time
+func WithTTLJitter(ctx context.Context, fraction float64) context.Context {
+	return context.WithValue(ctx, ttlJitterKey{}, fraction)
+}
+
+// JitterTTL returns ttl randomized uniformly within ±fraction of itself, so
+// keys written together don't all expire at the same instant. fraction is
+// clamped to [0, 1]; a non-positive ttl is returned unchanged, and the
+// result is at least a millisecond, since zero would mean no expiration.
+func JitterTTL(ttl time.Duration, fraction float64) time.Duration {
+	if ttl <= 0 || fraction <= 0 {
+		return ttl
+	}
+	fraction = min(fraction, 1)
+
+	spread := float64(ttl) * fraction
+	jittered := ttl + time.Duration(spread*(2*rand.Float64()-1))
+	return max(jittered, time.Millisecond)
+}
+
+// jitterTTL applies the per-call jitter from ctx, or fraction when none is set
+func jitterTTL(ctx context.Context, ttl time.Duration, fraction float64) time.Duration {
+	if override, ok := ctx.Value(ttlJitterKey{}).(float64); ok {
+		fraction = override
+	}
+	return JitterTTL(ttl, fraction)
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// deadlines returns the expiry of every key in an LRU cache
+func deadlines(c *LRUCache) []time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	expiries := make([]time.Time, 0, len(c.ttlMap))
+	for _, expiresAt := range c.ttlMap {
+		expiries = append(expiries, expiresAt)
+	}
+	return expiries
+}
+
+func TestTTLJitterSpreadsExpirations(t *testing.T) {
+	const (
+		keys     = 200
+		ttl      = time.Hour
+		fraction = 0.1
+	)
+	ctx := context.Background()
+	c := NewLRUCacheWithConfig(LRUConfig{Size: keys, TTLJitter: fraction}).(*LRUCache)
+
+	start := time.Now()
+	for i := 0; i < keys; i++ {
+		if err := c.SetWithTTL(ctx, "key:"+strconv.Itoa(i), i, ttl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	end := time.Now()
+
+	expiries := deadlines(c)
+	if len(expiries) != keys {
+		t.Fatalf("got %d deadlines, want %d", len(expiries), keys)
+	}
+
+	spread := time.Duration(float64(ttl) * fraction)
+	earliest, latest := expiries[0], expiries[0]
+	for _, expiresAt := range expiries {
+		if expiresAt.Before(start.Add(ttl-spread)) || expiresAt.After(end.Add(ttl+spread)) {
+			t.Fatalf("deadline %v outside ttl ±%v", expiresAt.Sub(start), spread)
+		}
+		if expiresAt.Before(earliest) {
+			earliest = expiresAt
+		}
+		if expiresAt.After(latest) {
+			latest = expiresAt
+		}
+	}
+
+	// Uniform over ±6 minutes: 200 deadlines cover well over half of the
+	// 12-minute range, where unjittered ones would share one instant
+	if got := latest.Sub(earliest); got < spread {
+		t.Fatalf("deadlines spread over %v, want at least %v", got, spread)
+	}
+}
+
+func TestTTLJitterOverride(t *testing.T) {
+	ctx := WithTTLJitter(context.Background(), 0)
+	c := NewLRUCacheWithConfig(LRUConfig{Size: 10, TTLJitter: 0.5}).(*LRUCache)
+
+	start := time.Now()
+	_ = c.SetWithTTL(ctx, "exact", 1, time.Hour)
+	end := time.Now()
+
+	expiresAt := deadlines(c)[0]
+	if expiresAt.Before(start.Add(time.Hour)) || expiresAt.After(end.Add(time.Hour)) {
+		t.Fatalf("deadline %v, want exactly the TTL with jitter disabled", expiresAt.Sub(start))
+	}
+}
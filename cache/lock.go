@@ -0,0 +1,199 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pengenjago/fibox/logging"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// lockKeyPrefix keeps lock keys apart from cached values
+const lockKeyPrefix = "lock:"
+
+var (
+	// ErrLockUnsupported is returned by Lock.TryLock when the cache has no lock support
+	ErrLockUnsupported = errors.New("cache does not support locks")
+	// ErrInvalidLockTTL is returned by Lock.TryLock for a ttl that isn't positive
+	ErrInvalidLockTTL = errors.New("lock ttl must be positive")
+)
+
+// unlockScript deletes KEYS[1] only while it still holds the owner token ARGV[1]
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// locker is implemented by caches that can hold locks
+type locker interface {
+	tryLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	unlock(ctx context.Context, key, token string) error
+}
+
+// Lock is a mutual exclusion lock keyed by name and held for at most a TTL,
+// e.g. so only one instance runs a scheduled job. With a RedisCache it is
+// shared by every process using the same Redis; with an LRUCache it only
+// covers the current process.
+type Lock struct {
+	cache Cache
+}
+
+// NewLock creates a lock backed by c. RedisCache, LRUCache and caches built
+// on them (Namespaced, TieredCache via its L2) are supported.
+func NewLock(c Cache) *Lock {
+	return &Lock{cache: c}
+}
+
+// TryLock acquires key for ttl without waiting. When acquired, release frees
+// the lock only if it's still held by this call, so a lock that expired and
+// was taken by someone else is left alone; release is safe to call more than
+// once. When not acquired, release is a no-op.
+func (l *Lock) TryLock(ctx context.Context, key string, ttl time.Duration) (acquired bool, release func(), err error) {
+	noop := func() {}
+
+	if ttl <= 0 {
+		return false, noop, ErrInvalidLockTTL
+	}
+
+	backend, ok := l.cache.(locker)
+	if !ok {
+		return false, noop, ErrLockUnsupported
+	}
+
+	token, err := lockToken()
+	if err != nil {
+		return false, noop, err
+	}
+
+	key = lockKeyPrefix + key
+	acquired, err = backend.tryLock(ctx, key, token, ttl)
+	if err != nil || !acquired {
+		return false, noop, err
+	}
+
+	var once sync.Once
+	release = func() {
+		once.Do(func() {
+			// The caller's context may be done by the time the lock is released
+			if err := backend.unlock(context.WithoutCancel(ctx), key, token); err != nil {
+				logging.ErrorWithFields("Lock release failed", err,
+					map[string]interface{}{
+						"key": key,
+					})
+			}
+		})
+	}
+	return true, release, nil
+}
+
+// lockToken returns a random owner token
+func lockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// lockSweepInterval bounds how often tryLock sweeps expired LRUCache locks,
+// for caches without a cleanup sweeper
+const lockSweepInterval = time.Minute
+
+// localLock is a lock held in an LRUCache
+type localLock struct {
+	token     string
+	expiresAt time.Time
+}
+
+func (c *LRUCache) tryLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	c.locksMu.Lock()
+	defer c.locksMu.Unlock()
+
+	if now.Sub(c.lastLockSweep) >= lockSweepInterval {
+		c.sweepLocks(now)
+	}
+
+	if held, ok := c.locks[key]; ok && now.Before(held.expiresAt) {
+		return false, nil
+	}
+	c.locks[key] = localLock{token: token, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// sweepLocks drops locks that expired without being released, e.g. by a
+// process that gave up on them; locksMu must be held
+func (c *LRUCache) sweepLocks(now time.Time) {
+	for key, held := range c.locks {
+		if !now.Before(held.expiresAt) {
+			delete(c.locks, key)
+		}
+	}
+	c.lastLockSweep = now
+}
+
+func (c *LRUCache) unlock(ctx context.Context, key, token string) error {
+	c.locksMu.Lock()
+	defer c.locksMu.Unlock()
+
+	if held, ok := c.locks[key]; ok && held.token == token {
+		delete(c.locks, key)
+	}
+	return nil
+}
+
+func (c *RedisCache) tryLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	acquired, err := c.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock %s: %w", key, err)
+	}
+	return acquired, nil
+}
+
+func (c *RedisCache) unlock(ctx context.Context, key, token string) error {
+	if err := unlockScript.Run(ctx, c.client, []string{key}, token).Err(); err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", key, err)
+	}
+	return nil
+}
+
+func (c *NamespacedCache) tryLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	backend, ok := c.cache.(locker)
+	if !ok {
+		return false, ErrLockUnsupported
+	}
+	return backend.tryLock(ctx, c.prefix+key, token, ttl)
+}
+
+func (c *NamespacedCache) unlock(ctx context.Context, key, token string) error {
+	backend, ok := c.cache.(locker)
+	if !ok {
+		return ErrLockUnsupported
+	}
+	return backend.unlock(ctx, c.prefix+key, token)
+}
+
+func (c *TieredCache) tryLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	backend, ok := c.l2.(locker)
+	if !ok {
+		return false, ErrLockUnsupported
+	}
+	return backend.tryLock(ctx, key, token, ttl)
+}
+
+func (c *TieredCache) unlock(ctx context.Context, key, token string) error {
+	backend, ok := c.l2.(locker)
+	if !ok {
+		return ErrLockUnsupported
+	}
+	return backend.unlock(ctx, key, token)
+}
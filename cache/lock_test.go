@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func lockCount(c *LRUCache) int {
+	c.locksMu.Lock()
+	defer c.locksMu.Unlock()
+	return len(c.locks)
+}
+
+// TestLRUCacheSweepsAbandonedLocks checks that locks left to expire are
+// dropped, by the cleanup sweeper and by a later TryLock without one
+func TestLRUCacheSweepsAbandonedLocks(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("sweeper", func(t *testing.T) {
+		c := NewLRUCacheWithConfig(LRUConfig{Size: 10, CleanupInterval: 10 * time.Millisecond}).(*LRUCache)
+		defer c.Close()
+
+		for i := 0; i < 5; i++ {
+			if acquired, _, err := NewLock(c).TryLock(ctx, "job:"+strconv.Itoa(i), 20*time.Millisecond); err != nil || !acquired {
+				t.Fatalf("TryLock = %v, %v", acquired, err)
+			}
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for lockCount(c) > 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("%d expired locks left after a second", lockCount(c))
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("try lock", func(t *testing.T) {
+		c := NewLRUCache(10).(*LRUCache)
+		for i := 0; i < 5; i++ {
+			_, _, _ = NewLock(c).TryLock(ctx, "job:"+strconv.Itoa(i), time.Millisecond)
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		// Due for a sweep
+		c.locksMu.Lock()
+		c.lastLockSweep = time.Now().Add(-lockSweepInterval)
+		c.locksMu.Unlock()
+
+		acquired, release, err := NewLock(c).TryLock(ctx, "other", time.Minute)
+		if err != nil || !acquired {
+			t.Fatalf("TryLock = %v, %v", acquired, err)
+		}
+		if n := lockCount(c); n != 1 {
+			t.Fatalf("%d locks held, want only the live one", n)
+		}
+
+		release()
+		if n := lockCount(c); n != 0 {
+			t.Fatalf("%d locks held after release, want 0", n)
+		}
+	})
+}
@@ -2,61 +2,277 @@ package cache
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/pengenjago/fibox/logging"
 
 	lru "github.com/hashicorp/golang-lru/v2"
+	"golang.org/x/sync/singleflight"
 )
 
+// ErrNotInteger is returned when incrementing a value that isn't an integer
+var ErrNotInteger = errors.New("cache value is not an integer")
+
 // Cache interface defines the operations for our cache wrapper
 type Cache interface {
 	Get(ctx context.Context, key string) (interface{}, bool)
+	GetMany(ctx context.Context, keys []string) (map[string]interface{}, error)
+	GetOrSet(ctx context.Context, key string, ttl time.Duration, loader func() (interface{}, error)) (interface{}, error)
 	Set(ctx context.Context, key string, value interface{}) error
 	SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	SetMany(ctx context.Context, items map[string]interface{}, ttl time.Duration) error
+	Increment(ctx context.Context, key string, delta int64) (int64, error)
+	IncrementWithTTL(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+	Decrement(ctx context.Context, key string, delta int64) (int64, error)
 	Delete(ctx context.Context, key string) error
 	DeleteByPattern(ctx context.Context, pattern string) error
+	DeleteByPatternWithOpts(ctx context.Context, pattern string, opts DeleteByPatternOpts) error
 	Clear(ctx context.Context) error
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	Len(ctx context.Context) int
+	PurgeExpired(ctx context.Context) (int, error)
+	Namespaced(namespace string) Cache
 	Stats() Stats
+	ResetStats(ctx context.Context)
+	Close() error
 }
 
-// Stats represents cache statistics
+// Stats represents cache statistics. Hits and Misses are read together, so
+// they are consistent with each other.
 type Stats struct {
 	Hits   int64
 	Misses int64
 	Size   int
+	// Expired counts entries removed because their TTL passed, whether found
+	// on Get, by the cleanup sweeper or by PurgeExpired
+	Expired int64
+}
+
+// HitRate returns Hits / (Hits + Misses), or 0 before any lookup
+func (s Stats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// LRUConfig holds LRU cache configuration
+type LRUConfig struct {
+	// Size is the maximum number of entries kept in the cache
+	Size int
+	// OnEvict is called whenever an entry leaves the cache: size-pressure
+	// eviction, TTL expiry detected on Get, Delete, DeleteByPattern and Clear.
+	// It is invoked without any cache lock held, so it may call back into the cache.
+	OnEvict func(key string, value interface{})
+	// CleanupInterval enables a background sweeper that removes expired
+	// entries at the given interval. Zero disables it, leaving expiry lazy on Get.
+	CleanupInterval time.Duration
+	// Codec, when set, stores values encoded and decodes a fresh copy on each
+	// read, so callers can't mutate a cached value through a returned
+	// reference. A TypedCodec such as JSONCodec decodes into the type the
+	// value was stored with; a plain Codec returns whatever Decode does.
+	// Nil stores the live value, which is fastest.
+	Codec Codec
+	// TTLJitter randomizes the TTL of SetWithTTL, SetMany and GetOrSet by
+	// up to ±TTLJitter of itself, e.g. 0.1 for ±10%, so entries written
+	// together don't expire together. Counters keep exact TTLs. Override
+	// per call with WithTTLJitter.
+	TTLJitter float64
 }
 
-// LRUCache implements the Cache interface using golang-lru
+// LRUCache implements the Cache interface using golang-lru.
+// It is safe for concurrent use: mu is held for every change to the entries
+// together with their ttlMap deadlines, so the two never disagree and
+// read-modify-write counter updates can't interleave with Set or Delete.
+// Reads go straight to golang-lru, and stats guards the hit/miss counters.
+// OnEvict callbacks are queued while mu is held and run by endWrite.
 type LRUCache struct {
-	cache  *lru.Cache[string, cacheItem]
-	stats  Stats
-	ttlMap map[string]time.Time
+	cache   *lru.Cache[string, cacheItem]
+	mu      sync.RWMutex
+	evicted []evictedItem
+	stats   statsCounter
+	ttlMap  map[string]time.Time
+	group   singleflight.Group
+	onEvict func(key string, value interface{})
+	codec   Codec
+	jitter  float64
+	stop    chan struct{}
+	once    sync.Once
+	// locks backs Lock for this process, apart from the cached entries
+	locksMu       sync.Mutex
+	locks         map[string]localLock
+	lastLockSweep time.Time
+}
+
+// evictedItem is an entry removed while mu was held, awaiting OnEvict
+type evictedItem struct {
+	key  string
+	item cacheItem
 }
 
 type cacheItem struct {
 	value     interface{}
 	expiresAt time.Time
+	// encoded is set when value holds Codec output rather than the live value
+	encoded bool
+	// typ is the type of the encoded value, nil for a nil value
+	typ reflect.Type
 }
 
 // NewLRUCache creates a new LRU cache with the specified size
 func NewLRUCache(size int) Cache {
-	cache, err := lru.New[string, cacheItem](size)
+	return NewLRUCacheWithConfig(LRUConfig{Size: size})
+}
+
+// NewLRUCacheWithConfig creates a new LRU cache with the given configuration
+func NewLRUCacheWithConfig(config LRUConfig) Cache {
+	c := &LRUCache{
+		ttlMap:        make(map[string]time.Time),
+		locks:         make(map[string]localLock),
+		lastLockSweep: time.Now(),
+		onEvict:       config.OnEvict,
+		codec:         config.Codec,
+		jitter:        config.TTLJitter,
+	}
+
+	cache, err := lru.NewWithEvict[string, cacheItem](config.Size, c.handleEvict)
 	if err != nil {
 		return nil
 	}
+	c.cache = cache
+
+	if config.CleanupInterval > 0 {
+		c.stop = make(chan struct{})
+		go c.runCleanup(config.CleanupInterval)
+	}
+
+	return c
+}
 
-	return &LRUCache{
-		cache:  cache,
-		ttlMap: make(map[string]time.Time),
+// runCleanup periodically removes expired entries until Close is called
+func (c *LRUCache) runCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.removeExpired()
+
+			c.locksMu.Lock()
+			c.sweepLocks(time.Now())
+			c.locksMu.Unlock()
+		case <-c.stop:
+			return
+		}
 	}
 }
 
+// removeExpired evicts every entry whose TTL has passed and returns the count.
+// The whole batch is removed under one write lock; OnEvict runs for it once
+// the lock is released.
+func (c *LRUCache) removeExpired() int {
+	now := time.Now()
+	count := 0
+
+	c.mu.Lock()
+	for key, expiresAt := range c.ttlMap {
+		if !now.After(expiresAt) {
+			continue
+		}
+		item, ok := c.cache.Peek(key)
+		if !ok || item.expiresAt.IsZero() || !now.After(item.expiresAt) {
+			continue
+		}
+		// handleEvict deletes the key from ttlMap, which is safe mid-range
+		if c.cache.Remove(key) {
+			count++
+		}
+	}
+	c.endWrite()
+
+	if count > 0 {
+		c.stats.expire(int64(count))
+		logging.DebugWithFields("Cache expired entries removed",
+			map[string]interface{}{
+				"count": count,
+			})
+	}
+	return count
+}
+
+// handleEvict is called by golang-lru after an entry has been removed and
+// after its internal lock has been released. Entries only leave the cache
+// while mu is held, so ttlMap is updated directly and OnEvict is queued for
+// endWrite.
+func (c *LRUCache) handleEvict(key string, item cacheItem) {
+	delete(c.ttlMap, key)
+
+	if c.onEvict != nil {
+		c.evicted = append(c.evicted, evictedItem{key: key, item: item})
+	}
+}
+
+// endWrite releases mu, then calls OnEvict for the entries removed while
+// it was held, so the callback may call back into the cache
+func (c *LRUCache) endWrite() {
+	evicted := c.evicted
+	c.evicted = nil
+	c.mu.Unlock()
+
+	for _, e := range evicted {
+		value, err := c.decode(e.item)
+		if err != nil {
+			logging.ErrorWithFields("Cache value decode failed", err,
+				map[string]interface{}{
+					"key": e.key,
+				})
+		}
+		c.onEvict(e.key, value)
+	}
+}
+
+// newItem builds the entry stored for value, encoding it if a codec is set
+func (c *LRUCache) newItem(key string, value interface{}, expiresAt time.Time) (cacheItem, error) {
+	if c.codec == nil {
+		return cacheItem{value: value, expiresAt: expiresAt}, nil
+	}
+
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return cacheItem{}, fmt.Errorf("failed to encode cache value for key %s: %w", key, err)
+	}
+	return cacheItem{value: data, expiresAt: expiresAt, encoded: true, typ: reflect.TypeOf(value)}, nil
+}
+
+// decode returns the value held by item, decoding it if it was encoded
+func (c *LRUCache) decode(item cacheItem) (interface{}, error) {
+	if !item.encoded {
+		return item.value, nil
+	}
+
+	data := item.value.([]byte)
+	if typed, ok := c.codec.(TypedCodec); ok && item.typ != nil {
+		target := reflect.New(item.typ)
+		if err := typed.DecodeInto(data, target.Interface()); err != nil {
+			return nil, err
+		}
+		return target.Elem().Interface(), nil
+	}
+	return c.codec.Decode(data)
+}
+
 // Get retrieves a value from the cache
 func (c *LRUCache) Get(ctx context.Context, key string) (interface{}, bool) {
 	item, ok := c.cache.Get(key)
 	if !ok {
-		c.stats.Misses++
+		c.stats.miss(1)
 		logging.DebugWithFields("Cache miss",
 			map[string]interface{}{
 				"key":       key,
@@ -67,9 +283,17 @@ func (c *LRUCache) Get(ctx context.Context, key string) (interface{}, bool) {
 
 	// Check if the item has expired
 	if !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
-		c.cache.Remove(key)
-		delete(c.ttlMap, key)
-		c.stats.Misses++
+		// Re-check under mu: a concurrent purge may have removed it already,
+		// or a write refreshed it
+		now := time.Now()
+		c.mu.Lock()
+		current, ok := c.cache.Peek(key)
+		removed := ok && !current.expiresAt.IsZero() && now.After(current.expiresAt) && c.cache.Remove(key)
+		c.endWrite()
+		if removed {
+			c.stats.expire(1)
+		}
+		c.stats.miss(1)
 		logging.DebugWithFields("Cache expired",
 			map[string]interface{}{
 				"key":       key,
@@ -78,23 +302,100 @@ func (c *LRUCache) Get(ctx context.Context, key string) (interface{}, bool) {
 		return nil, false
 	}
 
-	c.stats.Hits++
+	value, err := c.decode(item)
+	if err != nil {
+		c.stats.miss(1)
+		logging.ErrorWithFields("Cache value decode failed", err,
+			map[string]interface{}{
+				"key": key,
+			})
+		return nil, false
+	}
+
+	c.stats.hit(1)
 	logging.DebugWithFields("Cache hit",
 		map[string]interface{}{
 			"key":       key,
 			"cache_hit": true,
 		})
-	return item.value, true
+	return value, true
+}
+
+// GetMany retrieves several values at once. Keys that are missing or expired
+// are absent from the result, and each key counts as a hit or miss.
+func (c *LRUCache) GetMany(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if value, ok := c.Get(ctx, key); ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// GetOrSet returns the cached value for key, or invokes loader on a miss and
+// stores its result with the given TTL (zero means no expiration).
+// Concurrent misses for the same key share a single loader call, and a loader
+// error is returned without caching anything.
+func (c *LRUCache) GetOrSet(ctx context.Context, key string, ttl time.Duration, loader func() (interface{}, error)) (interface{}, error) {
+	if value, ok := c.Get(ctx, key); ok {
+		return value, nil
+	}
+
+	value, err, _ := c.group.Do(key, func() (interface{}, error) {
+		// Another caller may have populated the key while we were waiting
+		if value, ok := c.peek(key); ok {
+			return value, nil
+		}
+
+		value, err := loader()
+		if err != nil {
+			return nil, err
+		}
+
+		if ttl > 0 {
+			err = c.SetWithTTL(ctx, key, value, ttl)
+		} else {
+			err = c.Set(ctx, key, value)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return value, nil
+	})
+
+	return value, err
+}
+
+// peek returns a live value without updating recency or statistics
+func (c *LRUCache) peek(key string) (interface{}, bool) {
+	item, ok := c.cache.Peek(key)
+	if !ok {
+		return nil, false
+	}
+	if !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
+		return nil, false
+	}
+
+	value, err := c.decode(item)
+	if err != nil {
+		return nil, false
+	}
+	return value, true
 }
 
 // Set stores a value in the cache without TTL
 func (c *LRUCache) Set(ctx context.Context, key string, value interface{}) error {
-	item := cacheItem{
-		value:     value,
-		expiresAt: time.Time{}, // Zero time means no expiration
+	// Zero time means no expiration
+	item, err := c.newItem(key, value, time.Time{})
+	if err != nil {
+		return err
 	}
-	c.cache.Add(key, item)
-	delete(c.ttlMap, key) // Remove any existing TTL for this key
+
+	c.mu.Lock()
+	c.store(key, item)
+	c.endWrite()
 
 	logging.DebugWithFields("Cache set",
 		map[string]interface{}{
@@ -103,14 +404,21 @@ func (c *LRUCache) Set(ctx context.Context, key string, value interface{}) error
 	return nil
 }
 
-// SetWithTTL stores a value in the cache with a TTL
+// SetWithTTL stores a value in the cache with a TTL, jittered by TTLJitter
 func (c *LRUCache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
-	item := cacheItem{
-		value:     value,
-		expiresAt: time.Now().Add(ttl),
+	return c.setWithTTL(key, value, jitterTTL(ctx, ttl, c.jitter))
+}
+
+// setWithTTL stores a value with exactly the given TTL
+func (c *LRUCache) setWithTTL(key string, value interface{}, ttl time.Duration) error {
+	item, err := c.newItem(key, value, time.Now().Add(ttl))
+	if err != nil {
+		return err
 	}
-	c.cache.Add(key, item)
-	c.ttlMap[key] = item.expiresAt
+
+	c.mu.Lock()
+	c.store(key, item)
+	c.endWrite()
 
 	logging.DebugWithFields("Cache set with TTL",
 		map[string]interface{}{
@@ -120,10 +428,126 @@ func (c *LRUCache) SetWithTTL(ctx context.Context, key string, value interface{}
 	return nil
 }
 
+// store adds item under key and records its TTL, if any; mu must be held
+func (c *LRUCache) store(key string, item cacheItem) {
+	c.cache.Add(key, item)
+
+	if item.expiresAt.IsZero() {
+		delete(c.ttlMap, key) // Remove any existing TTL for this key
+	} else {
+		c.ttlMap[key] = item.expiresAt
+	}
+}
+
+// SetMany stores several values with the same TTL (zero means no expiration)
+func (c *LRUCache) SetMany(ctx context.Context, items map[string]interface{}, ttl time.Duration) error {
+	for key, value := range items {
+		var err error
+		if ttl > 0 {
+			err = c.SetWithTTL(ctx, key, value, ttl)
+		} else {
+			err = c.Set(ctx, key, value)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Increment atomically adds delta to an integer value and returns the new
+// total. A missing or expired key is created at delta without expiration,
+// while an existing key keeps its TTL.
+func (c *LRUCache) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	return c.IncrementWithTTL(ctx, key, delta, 0)
+}
+
+// IncrementWithTTL is Increment with a TTL applied only when the key is
+// created (zero means no expiration)
+func (c *LRUCache) IncrementWithTTL(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.endWrite()
+
+	now := time.Now()
+	current, ok := c.cache.Peek(key)
+	if !ok || (!current.expiresAt.IsZero() && now.After(current.expiresAt)) {
+		var expiresAt time.Time
+		if ttl > 0 {
+			expiresAt = now.Add(ttl)
+		}
+		item, err := c.newItem(key, delta, expiresAt)
+		if err != nil {
+			return 0, err
+		}
+		c.store(key, item)
+		return delta, nil
+	}
+
+	decoded, err := c.decode(current)
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment cache key %s: %w", key, err)
+	}
+
+	value, ok := toInt64(decoded)
+	if !ok {
+		return 0, fmt.Errorf("failed to increment cache key %s: %w", key, ErrNotInteger)
+	}
+
+	value += delta
+	item, err := c.newItem(key, value, current.expiresAt)
+	if err != nil {
+		return 0, err
+	}
+	c.store(key, item)
+
+	logging.DebugWithFields("Cache increment",
+		map[string]interface{}{
+			"key":   key,
+			"value": value,
+		})
+	return value, nil
+}
+
+// Decrement atomically subtracts delta from an integer value, see Increment
+func (c *LRUCache) Decrement(ctx context.Context, key string, delta int64) (int64, error) {
+	return c.Increment(ctx, key, -delta)
+}
+
+// toInt64 converts integer values stored by callers or by Increment,
+// including whole float64 values produced by decoding JSON
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
 // Delete removes a value from the cache
 func (c *LRUCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
 	c.cache.Remove(key)
-	delete(c.ttlMap, key)
+	c.endWrite()
 
 	logging.DebugWithFields("Cache delete",
 		map[string]interface{}{
@@ -134,8 +558,9 @@ func (c *LRUCache) Delete(ctx context.Context, key string) error {
 
 // Clear removes all values from the cache
 func (c *LRUCache) Clear(ctx context.Context) error {
+	c.mu.Lock()
 	c.cache.Purge()
-	c.ttlMap = make(map[string]time.Time)
+	c.endWrite()
 
 	logging.DebugWithFields("Cache cleared",
 		map[string]interface{}{
@@ -144,23 +569,29 @@ func (c *LRUCache) Clear(ctx context.Context) error {
 	return nil
 }
 
-// DeleteByPattern removes all cache entries that match the given pattern
+// DeleteByPattern removes all cache entries that match the given glob pattern.
+// Patterns follow Redis SCAN MATCH rules, e.g. "user:*:profile", "*:v1" or
+// "order:?". An empty pattern matches nothing.
 func (c *LRUCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+
 	keysToDelete := []string{}
 
-	// Get all keys in the cache
-	for key := range c.ttlMap {
-		// Simple pattern matching - in a real implementation, you might want to use regex
-		if c.matchesPattern(key, pattern) {
+	// Keys covers every entry, with or without TTL
+	for _, key := range c.cache.Keys() {
+		if matchPattern(pattern, key) {
 			keysToDelete = append(keysToDelete, key)
 		}
 	}
 
 	// Delete matching keys
+	c.mu.Lock()
 	for _, key := range keysToDelete {
 		c.cache.Remove(key)
-		delete(c.ttlMap, key)
 	}
+	c.endWrite()
 
 	logging.DebugWithFields("Cache delete by pattern",
 		map[string]interface{}{
@@ -171,19 +602,84 @@ func (c *LRUCache) DeleteByPattern(ctx context.Context, pattern string) error {
 	return nil
 }
 
-// matchesPattern checks if a key matches a simple pattern
-// Supports wildcard '*' at the end of the pattern
-func (c *LRUCache) matchesPattern(key, pattern string) bool {
-	// Simple implementation for patterns like "location:list:*"
-	if len(pattern) > 0 && pattern[len(pattern)-1] == '*' {
-		prefix := pattern[:len(pattern)-1]
-		return len(key) >= len(prefix) && key[:len(prefix)] == prefix
+// DeleteByPatternWithOpts is DeleteByPattern with matching options
+func (c *LRUCache) DeleteByPatternWithOpts(ctx context.Context, pattern string, opts DeleteByPatternOpts) error {
+	return c.DeleteByPattern(ctx, opts.apply(pattern))
+}
+
+// Keys returns the unexpired keys that match the given glob pattern, in no
+// particular order. Use "*" to list every key; an empty pattern matches nothing.
+func (c *LRUCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	now := time.Now()
+	keys := []string{}
+	if pattern == "" {
+		return keys, nil
+	}
+
+	for _, key := range c.cache.Keys() {
+		item, ok := c.cache.Peek(key)
+		if !ok || (!item.expiresAt.IsZero() && now.After(item.expiresAt)) {
+			continue
+		}
+		if matchPattern(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Len returns the number of unexpired entries in the cache
+func (c *LRUCache) Len(ctx context.Context) int {
+	now := time.Now()
+	expired := 0
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, expiresAt := range c.ttlMap {
+		if now.After(expiresAt) {
+			expired++
+		}
 	}
-	return key == pattern
+
+	// Expired entries stay in ttlMap until they leave the lru cache
+	return c.cache.Len() - expired
+}
+
+// PurgeExpired removes every expired entry now and returns how many were
+// removed, e.g. from an admin endpoint when CleanupInterval isn't set
+func (c *LRUCache) PurgeExpired(ctx context.Context) (int, error) {
+	return c.removeExpired(), nil
+}
+
+// Namespaced returns a view of the cache that prefixes keys with "<namespace>:"
+func (c *LRUCache) Namespaced(namespace string) Cache {
+	return NewNamespacedCache(c, namespace)
 }
 
 // Stats returns cache statistics
 func (c *LRUCache) Stats() Stats {
-	c.stats.Size = c.cache.Len()
-	return c.stats
+	hits, misses := c.stats.snapshot()
+	return Stats{
+		Hits:    hits,
+		Misses:  misses,
+		Size:    c.cache.Len(),
+		Expired: c.stats.expirations(),
+	}
+}
+
+// ResetStats sets the hit, miss and expired counters back to zero
+func (c *LRUCache) ResetStats(ctx context.Context) {
+	c.stats.reset()
+}
+
+// Close stops the background cleanup goroutine, if any.
+// The cache stays usable afterwards with lazy expiry only.
+func (c *LRUCache) Close() error {
+	if c.stop != nil {
+		c.once.Do(func() {
+			close(c.stop)
+		})
+	}
+	return nil
 }
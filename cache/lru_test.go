@@ -0,0 +1,273 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestLRUCacheConcurrentAccess hammers one cache from many goroutines; run
+// with -race to catch unguarded state
+func TestLRUCacheConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRUCache(64)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 16; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := "key:" + strconv.Itoa((g*31+i)%100)
+				switch i % 5 {
+				case 0:
+					_ = c.Set(ctx, key, i)
+				case 1:
+					_ = c.SetWithTTL(ctx, key, i, time.Millisecond)
+				case 2:
+					c.Get(ctx, key)
+				case 3:
+					_ = c.Delete(ctx, key)
+				case 4:
+					c.Len(ctx)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if n := c.Len(ctx); n < 0 || n > 64 {
+		t.Fatalf("Len() = %d, want between 0 and 64", n)
+	}
+}
+
+func TestLRUCacheDeleteByPattern(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		pattern string
+		deleted []string
+	}{
+		{"mid-pattern wildcard", "user:*:profile", []string{"user:1:profile", "user:22:profile"}},
+		{"mid-pattern single byte", "user:?:profile", []string{"user:1:profile"}},
+		{"trailing wildcard", "order:*", []string{"order:1", "order:2"}},
+		{"leading wildcard", "*:settings", []string{"user:1:settings"}},
+		{"empty pattern", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewLRUCache(100)
+			keys := []string{"user:1:profile", "user:22:profile", "user:1:settings", "order:1", "order:2"}
+			for i, key := range keys {
+				// Mix entries with and without TTL
+				if i%2 == 0 {
+					_ = c.Set(ctx, key, i)
+				} else {
+					_ = c.SetWithTTL(ctx, key, i, time.Hour)
+				}
+			}
+
+			if err := c.DeleteByPattern(ctx, tt.pattern); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, key := range keys {
+				_, found := c.Get(ctx, key)
+				if want := !slices.Contains(tt.deleted, key); found != want {
+					t.Errorf("after DeleteByPattern(%q), key %q present = %v, want %v", tt.pattern, key, found, want)
+				}
+			}
+		})
+	}
+}
+
+// slowCodec widens the window between an increment's read and its write
+type slowCodec struct{ JSONCodec }
+
+func (c slowCodec) Decode(data []byte) (interface{}, error) {
+	time.Sleep(100 * time.Microsecond)
+	return c.JSONCodec.Decode(data)
+}
+
+func (c slowCodec) DecodeInto(data []byte, target interface{}) error {
+	time.Sleep(100 * time.Microsecond)
+	return c.JSONCodec.DecodeInto(data, target)
+}
+
+// TestLRUCacheIncrementDoesNotLoseSet races increments against a Set: the
+// Set must never be overwritten by an increment that read the value before it
+func TestLRUCacheIncrementDoesNotLoseSet(t *testing.T) {
+	ctx := context.Background()
+	const reset = 1_000_000
+
+	for round := 0; round < 20; round++ {
+		c := NewLRUCacheWithConfig(LRUConfig{Size: 10, Codec: slowCodec{}})
+		_ = c.Set(ctx, "n", 0)
+
+		var wg sync.WaitGroup
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 20; i++ {
+					if _, err := c.Increment(ctx, "n", 1); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Land in the middle of the increments
+			time.Sleep(time.Millisecond)
+			_ = c.Set(ctx, "n", reset)
+		}()
+		wg.Wait()
+
+		value, _ := c.Get(ctx, "n")
+		if n, _ := toInt64(value); n < reset {
+			t.Fatalf("round %d: value = %d, the concurrent Set(%d) was lost", round, n, reset)
+		}
+	}
+}
+
+// TestLRUCacheOnEvictMayCallBack checks that OnEvict runs outside the cache
+// locks, so it can use the cache that evicted the entry
+func TestLRUCacheOnEvictMayCallBack(t *testing.T) {
+	ctx := context.Background()
+	var c Cache
+	var evicted []string
+	c = NewLRUCacheWithConfig(LRUConfig{
+		Size: 2,
+		OnEvict: func(key string, value interface{}) {
+			evicted = append(evicted, key)
+			_ = c.Set(ctx, "last-evicted", key)
+		},
+	})
+
+	_ = c.Set(ctx, "a", 1)
+	_ = c.Set(ctx, "b", 2)
+	_ = c.Delete(ctx, "a")
+	_, _ = c.Increment(ctx, "c", 1)
+
+	if !slices.Equal(evicted, []string{"a", "b"}) {
+		t.Fatalf("evicted = %v, want [a b]", evicted)
+	}
+	if last, _ := c.Get(ctx, "last-evicted"); last != "b" {
+		t.Fatalf("last-evicted = %v, want b", last)
+	}
+}
+
+// TestLRUCacheTTLMapMatchesEntries races TTL writes against deletes and
+// evictions while checking that every deadline in ttlMap belongs to an entry
+// with that TTL, and every entry with a TTL has its deadline recorded
+func TestLRUCacheTTLMapMatchesEntries(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRUCache(8).(*LRUCache)
+
+	consistent := func() error {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+
+		for key, expiresAt := range c.ttlMap {
+			if item, ok := c.cache.Peek(key); !ok || !item.expiresAt.Equal(expiresAt) {
+				return fmt.Errorf("ttlMap has %s, entry present = %v with deadline %v", key, ok, item.expiresAt)
+			}
+		}
+		for _, key := range c.cache.Keys() {
+			if item, ok := c.cache.Peek(key); ok && !item.expiresAt.IsZero() && !c.ttlMap[key].Equal(item.expiresAt) {
+				return fmt.Errorf("entry %s has a deadline missing from ttlMap", key)
+			}
+		}
+		return nil
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := "key:" + strconv.Itoa((g+i)%12)
+				switch i % 3 {
+				case 0:
+					_ = c.SetWithTTL(ctx, key, i, time.Hour)
+				case 1:
+					_ = c.Delete(ctx, key)
+				case 2:
+					_ = c.Set(ctx, key, i)
+				}
+			}
+		}(g)
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for {
+		if err := consistent(); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-done:
+			if err := consistent(); err != nil {
+				t.Fatal(err)
+			}
+			return
+		default:
+		}
+	}
+}
+
+func TestLRUCachePurgeExpired(t *testing.T) {
+	ctx := context.Background()
+	var c Cache
+	var evicted []string
+	c = NewLRUCacheWithConfig(LRUConfig{
+		Size: 10,
+		OnEvict: func(key string, value interface{}) {
+			evicted = append(evicted, key)
+			// Callbacks run after the purge releases the lock
+			_ = c.Len(ctx)
+		},
+	})
+
+	for _, key := range []string{"a", "b", "c"} {
+		_ = c.SetWithTTL(WithTTLJitter(ctx, 0), key, 1, time.Millisecond)
+	}
+	_ = c.SetWithTTL(ctx, "live", 1, time.Hour)
+	_ = c.Set(ctx, "forever", 1)
+	time.Sleep(5 * time.Millisecond)
+
+	n, err := c.PurgeExpired(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("PurgeExpired = %d, want 3", n)
+	}
+	if got := c.Stats().Expired; got != 3 {
+		t.Fatalf("Stats().Expired = %d, want 3", got)
+	}
+	slices.Sort(evicted)
+	if !slices.Equal(evicted, []string{"a", "b", "c"}) {
+		t.Fatalf("evicted = %v, want [a b c]", evicted)
+	}
+	if got := c.Len(ctx); got != 2 {
+		t.Fatalf("Len = %d, want 2", got)
+	}
+
+	if n, _ := c.PurgeExpired(ctx); n != 0 {
+		t.Fatalf("second PurgeExpired = %d, want 0", n)
+	}
+}
@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// namespaceSeparator joins a namespace and a key
+const namespaceSeparator = ":"
+
+// NamespacedCache is a view over a Cache that prefixes every key with
+// "<namespace>:". DeleteByPattern and Clear only affect keys in the namespace,
+// while Stats reports the underlying cache as a whole.
+type NamespacedCache struct {
+	cache  Cache
+	prefix string
+	// match is prefix escaped for glob patterns, so a namespace containing
+	// '*', '?' or '[' doesn't match keys of other namespaces
+	match string
+}
+
+// NewNamespacedCache creates a view over cache scoped to namespace
+func NewNamespacedCache(cache Cache, namespace string) Cache {
+	prefix := namespace + namespaceSeparator
+	return &NamespacedCache{
+		cache:  cache,
+		prefix: prefix,
+		match:  escapePattern(prefix),
+	}
+}
+
+// Namespaced returns a nested namespace, e.g. "users:sessions"
+func (c *NamespacedCache) Namespaced(namespace string) Cache {
+	return NewNamespacedCache(c.cache, c.prefix+namespace)
+}
+
+// Get retrieves a value from the namespace
+func (c *NamespacedCache) Get(ctx context.Context, key string) (interface{}, bool) {
+	return c.cache.Get(ctx, c.prefix+key)
+}
+
+// GetMany retrieves several values from the namespace, keyed without the prefix
+func (c *NamespacedCache) GetMany(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+
+	values, err := c.cache.GetMany(ctx, prefixed)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		result[strings.TrimPrefix(key, c.prefix)] = value
+	}
+	return result, nil
+}
+
+// GetOrSet returns the cached value for key in the namespace, loading it on a miss
+func (c *NamespacedCache) GetOrSet(ctx context.Context, key string, ttl time.Duration, loader func() (interface{}, error)) (interface{}, error) {
+	return c.cache.GetOrSet(ctx, c.prefix+key, ttl, loader)
+}
+
+// Set stores a value in the namespace without TTL
+func (c *NamespacedCache) Set(ctx context.Context, key string, value interface{}) error {
+	return c.cache.Set(ctx, c.prefix+key, value)
+}
+
+// SetWithTTL stores a value in the namespace with a TTL
+func (c *NamespacedCache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return c.cache.SetWithTTL(ctx, c.prefix+key, value, ttl)
+}
+
+// SetMany stores several values in the namespace with the same TTL
+func (c *NamespacedCache) SetMany(ctx context.Context, items map[string]interface{}, ttl time.Duration) error {
+	prefixed := make(map[string]interface{}, len(items))
+	for key, value := range items {
+		prefixed[c.prefix+key] = value
+	}
+	return c.cache.SetMany(ctx, prefixed, ttl)
+}
+
+// Increment atomically adds delta to an integer value in the namespace
+func (c *NamespacedCache) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	return c.cache.Increment(ctx, c.prefix+key, delta)
+}
+
+// IncrementWithTTL is Increment with a TTL applied only when the key is created
+func (c *NamespacedCache) IncrementWithTTL(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	return c.cache.IncrementWithTTL(ctx, c.prefix+key, delta, ttl)
+}
+
+// Decrement atomically subtracts delta from an integer value in the namespace
+func (c *NamespacedCache) Decrement(ctx context.Context, key string, delta int64) (int64, error) {
+	return c.cache.Decrement(ctx, c.prefix+key, delta)
+}
+
+// Delete removes a value from the namespace
+func (c *NamespacedCache) Delete(ctx context.Context, key string) error {
+	return c.cache.Delete(ctx, c.prefix+key)
+}
+
+// DeleteByPattern removes entries in the namespace that match pattern
+func (c *NamespacedCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+	return c.cache.DeleteByPattern(ctx, c.match+pattern)
+}
+
+// DeleteByPatternWithOpts is DeleteByPattern with matching options; the
+// namespace prefix itself is always matched exactly
+func (c *NamespacedCache) DeleteByPatternWithOpts(ctx context.Context, pattern string, opts DeleteByPatternOpts) error {
+	return c.DeleteByPattern(ctx, opts.apply(pattern))
+}
+
+// Clear removes every entry in the namespace, leaving other keys untouched
+func (c *NamespacedCache) Clear(ctx context.Context) error {
+	return c.cache.DeleteByPattern(ctx, c.match+"*")
+}
+
+// Keys returns the keys in the namespace that match pattern, without the prefix
+func (c *NamespacedCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	if pattern == "" {
+		return []string{}, nil
+	}
+
+	keys, err := c.cache.Keys(ctx, c.match+pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, c.prefix)
+	}
+	return keys, nil
+}
+
+// Len returns the number of keys in the namespace, or 0 if they can't be listed
+func (c *NamespacedCache) Len(ctx context.Context) int {
+	keys, err := c.cache.Keys(ctx, c.match+"*")
+	if err != nil {
+		return 0
+	}
+	return len(keys)
+}
+
+// PurgeExpired removes the expired entries of the whole underlying cache, not
+// just the namespace
+func (c *NamespacedCache) PurgeExpired(ctx context.Context) (int, error) {
+	return c.cache.PurgeExpired(ctx)
+}
+
+// Stats returns statistics of the whole underlying cache, not just the namespace
+func (c *NamespacedCache) Stats() Stats {
+	return c.cache.Stats()
+}
+
+// ResetStats resets the statistics of the whole underlying cache
+func (c *NamespacedCache) ResetStats(ctx context.Context) {
+	c.cache.ResetStats(ctx)
+}
+
+// Close is a no-op; the underlying cache is owned and closed by the caller
+func (c *NamespacedCache) Close() error {
+	return nil
+}
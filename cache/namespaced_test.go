@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// TestNamespacedCacheGlobNamespace checks that a namespace containing glob
+// metacharacters only lists and clears its own keys
+func TestNamespacedCacheGlobNamespace(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	caches := map[string]func() Cache{
+		"lru": func() Cache { return NewLRUCache(100) },
+		"redis": func() Cache {
+			mr.FlushAll()
+			return NewRedisCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+		},
+	}
+	namespaces := []string{"a*", "ab", "a?", "a[b]", "a\\", "a"}
+
+	for name, newCache := range caches {
+		for _, namespace := range namespaces {
+			t.Run(name+"/"+namespace, func(t *testing.T) {
+				base := newCache()
+				for _, ns := range namespaces {
+					if err := base.Namespaced(ns).Set(ctx, "k", ns); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				view := base.Namespaced(namespace)
+				keys, err := view.Keys(ctx, "*")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Equal(keys, []string{"k"}) {
+					t.Fatalf("Keys = %v, want [k]", keys)
+				}
+				if n := view.Len(ctx); n != 1 {
+					t.Fatalf("Len = %d, want 1", n)
+				}
+
+				if err := view.Clear(ctx); err != nil {
+					t.Fatal(err)
+				}
+				for _, ns := range namespaces {
+					_, found := base.Namespaced(ns).Get(ctx, "k")
+					if want := ns != namespace; found != want {
+						t.Errorf("after clearing %q, %q:k present = %v, want %v", namespace, ns, found, want)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package cache
+
+import "strings"
+
+// matchPattern reports whether key matches the glob pattern using the same
+// byte-wise rules as Redis SCAN MATCH, so a pattern behaves identically on
+// every backend: '*' matches any run of bytes (including '/' and ':'), '?'
+// matches one byte, [abc], [a-z] and [^a] match classes, and '\' escapes the
+// next byte.
+func matchPattern(pattern, key string) bool {
+	px, kx := 0, 0
+	// Position to resume from when a mismatch follows the last '*'
+	starPx, starKx := -1, 0
+
+	for px < len(pattern) || kx < len(key) {
+		if px < len(pattern) {
+			switch pattern[px] {
+			case '*':
+				starPx, starKx = px, kx
+				px++
+				continue
+			case '?':
+				if kx < len(key) {
+					px++
+					kx++
+					continue
+				}
+			case '[':
+				if kx < len(key) {
+					if ok, width := matchClass(pattern[px:], key[kx]); ok {
+						px += width
+						kx++
+						continue
+					}
+				}
+			default:
+				literal, width := pattern[px], 1
+				if literal == '\\' && px+1 < len(pattern) {
+					literal, width = pattern[px+1], 2
+				}
+				if kx < len(key) && key[kx] == literal {
+					px += width
+					kx++
+					continue
+				}
+			}
+		}
+
+		// Mismatch: let the last '*' swallow one more byte and retry
+		if starPx >= 0 && starKx < len(key) {
+			starKx++
+			px, kx = starPx+1, starKx
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// matchClass matches b against the character class at the start of class and
+// returns the class width. An unterminated '[' is treated as a literal.
+func matchClass(class string, b byte) (bool, int) {
+	i := 1
+	negate := i < len(class) && class[i] == '^'
+	if negate {
+		i++
+	}
+
+	matched := false
+	for i < len(class) && class[i] != ']' {
+		lo := class[i]
+		if lo == '\\' && i+1 < len(class) {
+			i++
+			lo = class[i]
+		}
+		hi := lo
+		if i+2 < len(class) && class[i+1] == '-' && class[i+2] != ']' {
+			hi = class[i+2]
+			i += 2
+		}
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		if lo <= b && b <= hi {
+			matched = true
+		}
+		i++
+	}
+
+	if i >= len(class) {
+		return b == '[', 1
+	}
+	return matched != negate, i + 1
+}
+
+// DeleteByPatternOpts adjusts how DeleteByPatternWithOpts matches keys
+type DeleteByPatternOpts struct {
+	// CaseInsensitive matches ASCII letters regardless of case, e.g. for keys
+	// built from user input. Redis has no such flag, so the pattern itself is
+	// rewritten ("user:*" becomes "[uU][sS][eE][rR]:*").
+	CaseInsensitive bool
+}
+
+// apply returns pattern rewritten according to the options
+func (o DeleteByPatternOpts) apply(pattern string) string {
+	if o.CaseInsensitive {
+		return caseInsensitivePattern(pattern)
+	}
+	return pattern
+}
+
+// caseInsensitivePattern rewrites a glob pattern so that every ASCII letter,
+// including those in classes and escapes, matches both cases
+func caseInsensitivePattern(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		switch {
+		case ch == '\\' && i+1 < len(pattern):
+			i++
+			if isLetter(pattern[i]) {
+				writeFolded(&b, pattern[i])
+			} else {
+				b.WriteByte('\\')
+				b.WriteByte(pattern[i])
+			}
+		case ch == '[':
+			_, width := matchClass(pattern[i:], 0)
+			if width == 1 {
+				// Unterminated, so a literal '['
+				b.WriteString(`\[`)
+				continue
+			}
+			b.WriteString(foldClass(pattern[i : i+width]))
+			i += width - 1
+		case isLetter(ch):
+			writeFolded(&b, ch)
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	return b.String()
+}
+
+// writeFolded writes a class matching both cases of letter, e.g. "[aA]"
+func writeFolded(b *strings.Builder, letter byte) {
+	b.WriteByte('[')
+	b.WriteByte(letter | 0x20)
+	b.WriteByte(letter &^ 0x20)
+	b.WriteByte(']')
+}
+
+// foldClass rewrites a character class so it also matches the other case of
+// every letter it contains
+func foldClass(class string) string {
+	negate := len(class) > 1 && class[1] == '^'
+	body := class
+	if negate {
+		body = "[" + class[2:]
+	}
+
+	var set [256]bool
+	for c := 0; c < 256; c++ {
+		if ok, _ := matchClass(body, byte(c)); ok {
+			set[c] = true
+			if isLetter(byte(c)) {
+				set[c^0x20] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteByte('[')
+	if negate {
+		b.WriteByte('^')
+	}
+	for lo := 0; lo < 256; lo++ {
+		if !set[lo] {
+			continue
+		}
+		hi := lo
+		for hi+1 < 256 && set[hi+1] {
+			hi++
+		}
+		writeClassRun(&b, lo, hi)
+		lo = hi
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// writeClassRun writes the bytes lo..hi inside a class. Range endpoints must
+// be plain bytes, as Redis doesn't accept escapes there, so special bytes at
+// either end are written individually.
+func writeClassRun(b *strings.Builder, lo, hi int) {
+	for lo <= hi && isClassSpecial(byte(lo)) {
+		writeClassByte(b, byte(lo))
+		lo++
+	}
+	end := hi
+	for hi >= lo && isClassSpecial(byte(hi)) {
+		hi--
+	}
+
+	if hi-lo >= 2 {
+		b.WriteByte(byte(lo))
+		b.WriteByte('-')
+		b.WriteByte(byte(hi))
+	} else {
+		for c := lo; c <= hi; c++ {
+			b.WriteByte(byte(c))
+		}
+	}
+
+	for c := hi + 1; c <= end; c++ {
+		writeClassByte(b, byte(c))
+	}
+}
+
+// writeClassByte writes c inside a class, escaped if it has special meaning
+func writeClassByte(b *strings.Builder, c byte) {
+	if isClassSpecial(c) {
+		b.WriteByte('\\')
+	}
+	b.WriteByte(c)
+}
+
+// isClassSpecial reports whether c needs escaping inside a class
+func isClassSpecial(c byte) bool {
+	return c == '\\' || c == ']' || c == '-' || c == '^' || c == '['
+}
+
+// isLetter reports whether c is an ASCII letter
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// escapePattern returns a glob pattern matching s literally
+func escapePattern(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+var patternTests = []struct {
+	pattern string
+	key     string
+	want    bool
+}{
+	{"user:1", "user:1", true},
+	{"user:1", "user:12", false},
+
+	// '*' in every position
+	{"*", "", true},
+	{"*", "anything:at/all", true},
+	{"*:profile", "user:1:profile", true},
+	{"*:profile", "user:1:settings", false},
+	{"user:*", "user:", true},
+	{"user:*", "user:1:profile", true},
+	{"user:*", "order:1", false},
+	{"user:*:profile", "user:1:profile", true},
+	{"user:*:profile", "user:1:2:profile", true},
+	{"user:*:profile", "user::profile", true},
+	{"user:*:profile", "user:1:settings", false},
+	{"*user*", "olduser:1", true},
+	{"a*b*c", "abc", true},
+	{"a*b*c", "axxbyyc", true},
+	{"a*b*c", "axxbyy", false},
+
+	// '?' in every position
+	{"?ser:1", "user:1", true},
+	{"user:?", "user:1", true},
+	{"user:?", "user:12", false},
+	{"user:?", "user:", false},
+	{"user:?:profile", "user:1:profile", true},
+	{"user:?:profile", "user:12:profile", false},
+
+	// Classes
+	{"user:[12]", "user:1", true},
+	{"user:[12]", "user:3", false},
+	{"user:[0-9]", "user:7", true},
+	{"user:[9-0]", "user:7", true},
+	{"user:[^0-9]", "user:a", true},
+	{"user:[^0-9]", "user:7", false},
+	{"user:[a\\]]", "user:]", true},
+
+	// Escapes
+	{"user\\*", "user*", true},
+	{"user\\*", "user:1", false},
+	{"what\\?", "what?", true},
+	{"what\\?", "whatx", false},
+	{"\\[x]", "[x]", true},
+
+	// An unterminated class is a literal '['
+	{"user:[", "user:[", true},
+	{"user:[", "user:1", false},
+
+	// The empty pattern only matches the empty key here; the cache methods
+	// treat it as matching nothing
+	{"", "", true},
+	{"", "user:1", false},
+}
+
+func TestMatchPattern(t *testing.T) {
+	for _, tt := range patternTests {
+		if got := matchPattern(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestEscapePattern(t *testing.T) {
+	for _, s := range []string{"plain", "a*", "a?b", "[x]", "back\\slash", "*?[]\\"} {
+		pattern := escapePattern(s)
+		if !matchPattern(pattern, s) {
+			t.Errorf("escapePattern(%q) = %q doesn't match itself", s, pattern)
+		}
+		if matchPattern(pattern, s+"x") || matchPattern(pattern, "x"+s) {
+			t.Errorf("escapePattern(%q) = %q matches more than itself", s, pattern)
+		}
+	}
+}
+
+func TestCaseInsensitivePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"user:*", "USER:1", true},
+		{"user:*", "User:1", true},
+		{"User:[a-c]", "user:B", true},
+		{"User:[a-c]", "user:D", false},
+		{"user:[^a]", "user:A", false},
+		{"user\\x", "USERX", true},
+		{"user:[", "USER:[", true},
+		{"1:*", "1:ABC", true},
+	}
+
+	for _, tt := range tests {
+		pattern := caseInsensitivePattern(tt.pattern)
+		if got := matchPattern(pattern, tt.key); got != tt.want {
+			t.Errorf("case-insensitive %q (%q) against %q = %v, want %v", tt.pattern, pattern, tt.key, got, tt.want)
+		}
+	}
+}
+
+// TestPatternParity checks that the LRU and Redis caches select the same keys
+func TestPatternParity(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	caches := map[string]Cache{
+		"lru":   NewLRUCache(100),
+		"redis": NewRedisCache(redis.NewClient(&redis.Options{Addr: mr.Addr()})),
+	}
+
+	keys := []string{
+		"user:1", "user:12", "user:a", "user:]", "user:[", "user*", "USER:1",
+		"user:1:profile", "user:12:profile", "user:1:settings", "order:1", "what?",
+	}
+	for _, c := range caches {
+		for _, key := range keys {
+			if err := c.Set(ctx, key, 1); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	patterns := []string{
+		"*", "user:*", "*:profile", "user:*:profile", "user:?", "user:?:profile",
+		"user:[12]", "user:[0-9]*", "user:[^0-9]", "user\\*", "what\\?", "", "nomatch*",
+	}
+	for _, pattern := range patterns {
+		var want []string
+		for _, key := range keys {
+			if pattern != "" && matchPattern(pattern, key) {
+				want = append(want, key)
+			}
+		}
+		slices.Sort(want)
+
+		for name, c := range caches {
+			got, err := c.Keys(ctx, pattern)
+			if err != nil {
+				t.Fatalf("%s Keys(%q): %v", name, pattern, err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Errorf("%s Keys(%q) = %v, want %v", name, pattern, got, want)
+			}
+		}
+	}
+}
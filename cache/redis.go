@@ -0,0 +1,455 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pengenjago/fibox/logging"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// scanBatchSize is the COUNT hint used when scanning keys
+const scanBatchSize = 100
+
+// incrementScript adds ARGV[1] to KEYS[1] and, if the key didn't exist yet,
+// expires it after ARGV[2] milliseconds (zero means no expiration)
+var incrementScript = redis.NewScript(`
+local existed = redis.call('EXISTS', KEYS[1])
+local value = redis.call('INCRBY', KEYS[1], ARGV[1])
+if existed == 0 and tonumber(ARGV[2]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return value
+`)
+
+// RedisConfig holds Redis cache configuration
+type RedisConfig struct {
+	// Client is the Redis client, owned and closed by the caller
+	Client redis.UniversalClient
+	// Codec serializes values, default JSONCodec. Increment requires integers
+	// to be encoded as plain decimal text, as JSONCodec does.
+	Codec Codec
+	// TTLJitter randomizes the TTL of SetWithTTL, SetMany and GetOrSet by
+	// up to ±TTLJitter of itself, e.g. 0.1 for ±10%, so keys written together
+	// don't expire together and stampede the backend. Counters keep exact
+	// TTLs. Override per call with WithTTLJitter.
+	TTLJitter float64
+}
+
+// RedisCache implements the Cache interface using Redis.
+// Values are serialized with the configured Codec; with the default JSONCodec
+// Get returns them decoded into generic JSON types (map[string]interface{},
+// []interface{}, float64, string, bool). Hit/miss counters are tracked in-process.
+type RedisCache struct {
+	client redis.UniversalClient
+	codec  Codec
+	jitter float64
+	stats  statsCounter
+	group  singleflight.Group
+}
+
+// NewRedisCache creates a new Redis cache using the given client
+func NewRedisCache(client redis.UniversalClient) Cache {
+	return NewRedisCacheWithConfig(RedisConfig{Client: client})
+}
+
+// NewRedisCacheWithConfig creates a new Redis cache with the given configuration
+func NewRedisCacheWithConfig(config RedisConfig) Cache {
+	if config.Codec == nil {
+		config.Codec = JSONCodec{}
+	}
+
+	return &RedisCache{
+		client: config.Client,
+		codec:  config.Codec,
+		jitter: config.TTLJitter,
+	}
+}
+
+// Get retrieves a value from the cache
+func (c *RedisCache) Get(ctx context.Context, key string) (interface{}, bool) {
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		c.stats.miss(1)
+		if !errors.Is(err, redis.Nil) {
+			logging.ErrorWithFields("Cache get failed", err,
+				map[string]interface{}{
+					"key": key,
+				})
+			return nil, false
+		}
+		logging.DebugWithFields("Cache miss",
+			map[string]interface{}{
+				"key":       key,
+				"cache_hit": false,
+			})
+		return nil, false
+	}
+
+	value, err := c.codec.Decode(data)
+	if err != nil {
+		c.stats.miss(1)
+		logging.ErrorWithFields("Cache value decode failed", err,
+			map[string]interface{}{
+				"key": key,
+			})
+		return nil, false
+	}
+
+	c.stats.hit(1)
+	logging.DebugWithFields("Cache hit",
+		map[string]interface{}{
+			"key":       key,
+			"cache_hit": true,
+		})
+	return value, true
+}
+
+// GetMany retrieves several values with a single MGET. Keys that are missing
+// or can't be decoded are absent from the result, and each key counts as a
+// hit or miss. With Redis Cluster all keys must share a hash slot.
+func (c *RedisCache) GetMany(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+
+	results, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		c.stats.miss(int64(len(keys)))
+		return nil, fmt.Errorf("failed to get cache keys: %w", err)
+	}
+
+	for i, result := range results {
+		data, ok := result.(string)
+		if !ok {
+			c.stats.miss(1)
+			continue
+		}
+
+		value, err := c.codec.Decode([]byte(data))
+		if err != nil {
+			c.stats.miss(1)
+			logging.ErrorWithFields("Cache value decode failed", err,
+				map[string]interface{}{
+					"key": keys[i],
+				})
+			continue
+		}
+
+		c.stats.hit(1)
+		values[keys[i]] = value
+	}
+
+	logging.DebugWithFields("Cache get many",
+		map[string]interface{}{
+			"keys": len(keys),
+			"hits": len(values),
+		})
+	return values, nil
+}
+
+// GetOrSet returns the cached value for key, or invokes loader on a miss and
+// stores its result with the given TTL (zero means no expiration).
+// Concurrent misses for the same key within this process share a single
+// loader call, and a loader error is returned without caching anything.
+func (c *RedisCache) GetOrSet(ctx context.Context, key string, ttl time.Duration, loader func() (interface{}, error)) (interface{}, error) {
+	if value, ok := c.Get(ctx, key); ok {
+		return value, nil
+	}
+
+	value, err, _ := c.group.Do(key, func() (interface{}, error) {
+		value, err := loader()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.SetWithTTL(ctx, key, value, ttl); err != nil {
+			return nil, err
+		}
+
+		return value, nil
+	})
+
+	return value, err
+}
+
+// Set stores a value in the cache without TTL
+func (c *RedisCache) Set(ctx context.Context, key string, value interface{}) error {
+	return c.set(ctx, key, value, 0)
+}
+
+// SetWithTTL stores a value in the cache with a TTL, jittered by TTLJitter
+func (c *RedisCache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return c.set(ctx, key, value, jitterTTL(ctx, ttl, c.jitter))
+}
+
+func (c *RedisCache) set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache value for key %s: %w", key, err)
+	}
+
+	if err := c.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set cache key %s: %w", key, err)
+	}
+
+	logging.DebugWithFields("Cache set",
+		map[string]interface{}{
+			"key":      key,
+			"duration": ttl.String(),
+		})
+	return nil
+}
+
+// SetMany stores several values with the same TTL (zero means no expiration)
+// in one pipelined round trip, jittered per key by TTLJitter. Values are
+// encoded up front, so an encoding error stores nothing.
+func (c *RedisCache) SetMany(ctx context.Context, items map[string]interface{}, ttl time.Duration) error {
+	encoded := make(map[string][]byte, len(items))
+	for key, value := range items {
+		data, err := c.codec.Encode(value)
+		if err != nil {
+			return fmt.Errorf("failed to encode cache value for key %s: %w", key, err)
+		}
+		encoded[key] = data
+	}
+
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, data := range encoded {
+			pipe.Set(ctx, key, data, jitterTTL(ctx, ttl, c.jitter))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set cache keys: %w", err)
+	}
+
+	logging.DebugWithFields("Cache set many",
+		map[string]interface{}{
+			"count":    len(items),
+			"duration": ttl.String(),
+		})
+	return nil
+}
+
+// Increment atomically adds delta to an integer value with INCRBY and returns
+// the new total. A missing key is created at delta without expiration.
+func (c *RedisCache) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	return c.IncrementWithTTL(ctx, key, delta, 0)
+}
+
+// IncrementWithTTL is Increment with a TTL applied only when the key is
+// created (zero means no expiration)
+func (c *RedisCache) IncrementWithTTL(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	value, err := incrementScript.Run(ctx, c.client, []string{key}, delta, ttl.Milliseconds()).Int64()
+	if err != nil {
+		if strings.Contains(err.Error(), "not an integer") {
+			return 0, fmt.Errorf("failed to increment cache key %s: %w", key, ErrNotInteger)
+		}
+		return 0, fmt.Errorf("failed to increment cache key %s: %w", key, err)
+	}
+
+	logging.DebugWithFields("Cache increment",
+		map[string]interface{}{
+			"key":   key,
+			"value": value,
+		})
+	return value, nil
+}
+
+// Decrement atomically subtracts delta from an integer value, see Increment
+func (c *RedisCache) Decrement(ctx context.Context, key string, delta int64) (int64, error) {
+	return c.Increment(ctx, key, -delta)
+}
+
+// Delete removes a value from the cache
+func (c *RedisCache) Delete(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to delete cache key %s: %w", key, err)
+	}
+
+	logging.DebugWithFields("Cache delete",
+		map[string]interface{}{
+			"key": key,
+		})
+	return nil
+}
+
+// DeleteByPattern removes all cache entries that match the given Redis glob
+// pattern. Keys are discovered with SCAN so the server is never blocked by KEYS;
+// on a ClusterClient every master is scanned. An empty pattern matches nothing,
+// rather than every key as SCAN would.
+func (c *RedisCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+
+	count := 0
+	err := c.scan(ctx, pattern, func(keys []string) error {
+		if err := c.deleteKeys(ctx, keys); err != nil {
+			return fmt.Errorf("failed to delete cache keys by pattern %s: %w", pattern, err)
+		}
+		count += len(keys)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.DebugWithFields("Cache delete by pattern",
+		map[string]interface{}{
+			"pattern": pattern,
+			"count":   count,
+		})
+
+	return nil
+}
+
+// DeleteByPatternWithOpts is DeleteByPattern with matching options
+func (c *RedisCache) DeleteByPatternWithOpts(ctx context.Context, pattern string, opts DeleteByPatternOpts) error {
+	return c.DeleteByPattern(ctx, opts.apply(pattern))
+}
+
+// Clear removes all values from the currently selected Redis database, or from
+// every master of a ClusterClient
+func (c *RedisCache) Clear(ctx context.Context) error {
+	var err error
+	if cluster, ok := c.client.(*redis.ClusterClient); ok {
+		err = cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return node.FlushDB(ctx).Err()
+		})
+	} else {
+		err = c.client.FlushDB(ctx).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to clear cache: %w", err)
+	}
+
+	logging.Debug("Cache cleared")
+	return nil
+}
+
+// Keys returns the keys that match the given Redis glob pattern, discovered
+// with SCAN on every master of a ClusterClient. The result may contain
+// duplicates if keys change during the scan. An empty pattern matches nothing.
+func (c *RedisCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	keys := []string{}
+	if pattern == "" {
+		return keys, nil
+	}
+
+	err := c.scan(ctx, pattern, func(batch []string) error {
+		keys = append(keys, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// scan calls fn with batches of up to scanBatchSize keys matching pattern,
+// one call at a time. SCAN only covers the node it runs on, so a
+// ClusterClient is scanned on each master.
+func (c *RedisCache) scan(ctx context.Context, pattern string, fn func(keys []string) error) error {
+	var mu sync.Mutex
+	scanNode := func(ctx context.Context, client redis.UniversalClient) error {
+		iter := client.Scan(ctx, 0, pattern, scanBatchSize).Iterator()
+		batch := make([]string, 0, scanBatchSize)
+		flush := func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			err := fn(batch)
+			batch = batch[:0]
+			return err
+		}
+
+		for iter.Next(ctx) {
+			batch = append(batch, iter.Val())
+			if len(batch) == scanBatchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return fmt.Errorf("failed to scan cache keys by pattern %s: %w", pattern, err)
+		}
+		if len(batch) > 0 {
+			return flush()
+		}
+		return nil
+	}
+
+	if cluster, ok := c.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scanNode(ctx, node)
+		})
+	}
+	return scanNode(ctx, c.client)
+}
+
+// deleteKeys removes keys. A ClusterClient deletes them one per command in a
+// pipeline, since a multi-key DEL fails when the keys span hash slots.
+func (c *RedisCache) deleteKeys(ctx context.Context, keys []string) error {
+	if _, ok := c.client.(*redis.ClusterClient); !ok {
+		return c.client.Del(ctx, keys...).Err()
+	}
+
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Del(ctx, key)
+		}
+		return nil
+	})
+	return err
+}
+
+// Len returns the number of keys in the Redis database (DBSIZE), or 0 if the
+// lookup fails
+func (c *RedisCache) Len(ctx context.Context) int {
+	size, err := c.client.DBSize(ctx).Result()
+	if err != nil {
+		logging.Error("Cache size lookup failed", err)
+		return 0
+	}
+	return int(size)
+}
+
+// Namespaced returns a view of the cache that prefixes keys with "<namespace>:"
+func (c *RedisCache) Namespaced(namespace string) Cache {
+	return NewNamespacedCache(c, namespace)
+}
+
+// PurgeExpired is a no-op returning 0: Redis removes expired keys itself
+func (c *RedisCache) PurgeExpired(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+// Stats returns cache statistics, with Size taken from DBSIZE. Expired is
+// always 0, since Redis expires keys on its own.
+func (c *RedisCache) Stats() Stats {
+	hits, misses := c.stats.snapshot()
+	return Stats{
+		Hits:   hits,
+		Misses: misses,
+		Size:   c.Len(context.Background()),
+	}
+}
+
+// ResetStats sets the in-process hit and miss counters back to zero
+func (c *RedisCache) ResetStats(ctx context.Context) {
+	c.stats.reset()
+}
+
+// Close is a no-op; the Redis client is owned and closed by the caller
+func (c *RedisCache) Close() error {
+	return nil
+}
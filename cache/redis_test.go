@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTwoNodeCluster returns a ClusterClient splitting the hash slots between
+// two servers, and the servers
+func newTwoNodeCluster(t *testing.T) (*redis.ClusterClient, [2]*miniredis.Miniredis) {
+	t.Helper()
+	nodes := [2]*miniredis.Miniredis{miniredis.RunT(t), miniredis.RunT(t)}
+	client := redis.NewClusterClient(&redis.ClusterOptions{
+		ClusterSlots: func(context.Context) ([]redis.ClusterSlot, error) {
+			return []redis.ClusterSlot{
+				{Start: 0, End: 8191, Nodes: []redis.ClusterNode{{Addr: nodes[0].Addr()}}},
+				{Start: 8192, End: 16383, Nodes: []redis.ClusterNode{{Addr: nodes[1].Addr()}}},
+			}, nil
+		},
+	})
+	t.Cleanup(func() { _ = client.Close() })
+	return client, nodes
+}
+
+func TestRedisCacheClusterScansEveryMaster(t *testing.T) {
+	ctx := context.Background()
+	client, nodes := newTwoNodeCluster(t)
+	c := NewRedisCache(client)
+
+	var want []string
+	for i := range 20 {
+		key := fmt.Sprintf("user:%d", i)
+		want = append(want, key)
+		if err := c.Set(ctx, key, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Set(ctx, "other", 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes[0].Keys()) == 0 || len(nodes[1].Keys()) == 0 {
+		t.Fatalf("keys all landed on one node, the test needs both")
+	}
+
+	keys, err := c.Keys(ctx, "user:*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(keys)
+	slices.Sort(want)
+	if !slices.Equal(keys, want) {
+		t.Fatalf("Keys = %v, want %v", keys, want)
+	}
+
+	if err := c.DeleteByPattern(ctx, "user:*"); err != nil {
+		t.Fatal(err)
+	}
+	if n := c.Len(ctx); n != 1 {
+		t.Fatalf("Len after DeleteByPattern = %d, want 1", n)
+	}
+
+	if err := c.Clear(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(nodes[0].Keys()) + len(nodes[1].Keys()); n != 0 {
+		t.Fatalf("%d keys left after Clear, want 0", n)
+	}
+}
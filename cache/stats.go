@@ -0,0 +1,54 @@
+package cache
+
+import "sync"
+
+// statsCounter tracks hits, misses and expirations under one lock so that a snapshot or
+// reset never observes one counter updated without the other
+type statsCounter struct {
+	mu      sync.Mutex
+	hits    int64
+	misses  int64
+	expired int64
+}
+
+// hit records n cache hits
+func (s *statsCounter) hit(n int64) {
+	s.mu.Lock()
+	s.hits += n
+	s.mu.Unlock()
+}
+
+// miss records n cache misses
+func (s *statsCounter) miss(n int64) {
+	s.mu.Lock()
+	s.misses += n
+	s.mu.Unlock()
+}
+
+// expire records n entries removed because their TTL passed
+func (s *statsCounter) expire(n int64) {
+	s.mu.Lock()
+	s.expired += n
+	s.mu.Unlock()
+}
+
+// expirations returns the number of entries removed because their TTL passed
+func (s *statsCounter) expirations() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expired
+}
+
+// snapshot returns the current hits and misses
+func (s *statsCounter) snapshot() (hits, misses int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hits, s.misses
+}
+
+// reset sets all counters back to zero
+func (s *statsCounter) reset() {
+	s.mu.Lock()
+	s.hits, s.misses, s.expired = 0, 0, 0
+	s.mu.Unlock()
+}
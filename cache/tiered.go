@@ -0,0 +1,286 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DefaultL1TTL bounds how long the L1 tier of a TieredCache keeps an entry
+const DefaultL1TTL = time.Minute
+
+// TieredConfig holds two-tier cache configuration
+type TieredConfig struct {
+	// L1 is the fast local tier, typically an LRUCache
+	L1 Cache
+	// L2 is the shared tier, typically a RedisCache. It is authoritative for
+	// Keys, Len and counters.
+	L2 Cache
+	// L1TTL caps the lifetime of L1 entries, default DefaultL1TTL
+	L1TTL time.Duration
+}
+
+// TieredCache composes a local L1 cache in front of a shared L2 cache.
+// Reads check L1 first and fall back to L2, copying L2 hits into L1 for no
+// longer than they have left in L2. Writes and deletes go to L2 first and
+// then L1.
+//
+// L1 copies on other nodes don't see an L2 update or delete: without pub/sub
+// invalidation they stay stale for up to L1TTL. Values served from L1 are the
+// ones stored by this process, while values copied from a serializing L2 (such
+// as RedisCache) come back as decoded JSON types.
+type TieredCache struct {
+	l1    Cache
+	l2    Cache
+	l1TTL time.Duration
+	stats statsCounter
+}
+
+// NewTieredCache creates a two-tier cache with the default L1 TTL
+func NewTieredCache(l1 Cache, l2 Cache) Cache {
+	return NewTieredCacheWithConfig(TieredConfig{
+		L1: l1,
+		L2: l2,
+	})
+}
+
+// NewTieredCacheWithConfig creates a two-tier cache with custom configuration
+func NewTieredCacheWithConfig(config TieredConfig) Cache {
+	if config.L1TTL <= 0 {
+		config.L1TTL = DefaultL1TTL
+	}
+
+	return &TieredCache{
+		l1:    config.L1,
+		l2:    config.L2,
+		l1TTL: config.L1TTL,
+	}
+}
+
+// l1TTLFor returns the L1 TTL for an entry stored in L2 with ttl
+func (c *TieredCache) l1TTLFor(ttl time.Duration) time.Duration {
+	if ttl > 0 && ttl < c.l1TTL {
+		return ttl
+	}
+	return c.l1TTL
+}
+
+// fillL1 copies L2 hits into L1, each for at most its remaining L2 TTL. An
+// L2 that can't report TTLs gets the plain L1TTL; keys whose TTL can't be
+// read, or that expired meanwhile, aren't copied. Populating L1 is best
+// effort, as the values are served from L2 anyway.
+func (c *TieredCache) fillL1(ctx context.Context, found map[string]interface{}) {
+	backend, ok := c.l2.(ttlReader)
+	if !ok {
+		_ = c.l1.SetMany(ctx, found, c.l1TTL)
+		return
+	}
+
+	keys := make([]string, 0, len(found))
+	for key := range found {
+		keys = append(keys, key)
+	}
+
+	ttls, err := backend.remainingTTLs(ctx, keys)
+	if errors.Is(err, errTTLUnsupported) {
+		_ = c.l1.SetMany(ctx, found, c.l1TTL)
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	for key, value := range found {
+		ttl, ok := ttls[key]
+		if !ok {
+			continue
+		}
+		ttl = c.l1TTLFor(ttl)
+		// Jitter could push a short remaining TTL past the L2 expiry
+		_ = c.l1.SetWithTTL(WithTTLJitter(ctx, 0), key, value, ttl)
+	}
+}
+
+// Get retrieves a value from L1, or from L2 on an L1 miss
+func (c *TieredCache) Get(ctx context.Context, key string) (interface{}, bool) {
+	if value, ok := c.l1.Get(ctx, key); ok {
+		c.stats.hit(1)
+		return value, true
+	}
+
+	value, ok := c.l2.Get(ctx, key)
+	if !ok {
+		c.stats.miss(1)
+		return nil, false
+	}
+
+	c.fillL1(ctx, map[string]interface{}{key: value})
+	c.stats.hit(1)
+	return value, true
+}
+
+// GetMany retrieves several values, asking L2 only for the keys L1 misses
+func (c *TieredCache) GetMany(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	values, err := c.l1.GetMany(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make([]string, 0, len(keys)-len(values))
+	for _, key := range keys {
+		if _, ok := values[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		found, err := c.l2.GetMany(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(found) > 0 {
+			c.fillL1(ctx, found)
+		}
+		for key, value := range found {
+			values[key] = value
+		}
+	}
+
+	c.stats.hit(int64(len(values)))
+	c.stats.miss(int64(len(keys) - len(values)))
+	return values, nil
+}
+
+// GetOrSet returns the cached value for key, or loads it through L2 on a miss
+// in both tiers. Loader deduplication is left to L2.
+func (c *TieredCache) GetOrSet(ctx context.Context, key string, ttl time.Duration, loader func() (interface{}, error)) (interface{}, error) {
+	if value, ok := c.Get(ctx, key); ok {
+		return value, nil
+	}
+
+	value, err := c.l2.GetOrSet(ctx, key, ttl, loader)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = c.l1.SetWithTTL(ctx, key, value, c.l1TTLFor(ttl))
+	return value, nil
+}
+
+// Set stores a value in both tiers, without TTL in L2
+func (c *TieredCache) Set(ctx context.Context, key string, value interface{}) error {
+	return c.SetWithTTL(ctx, key, value, 0)
+}
+
+// SetWithTTL stores a value in both tiers. L1 keeps it for at most L1TTL.
+func (c *TieredCache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	var err error
+	if ttl > 0 {
+		err = c.l2.SetWithTTL(ctx, key, value, ttl)
+	} else {
+		err = c.l2.Set(ctx, key, value)
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.l1.SetWithTTL(ctx, key, value, c.l1TTLFor(ttl))
+}
+
+// SetMany stores several values in both tiers with the same TTL
+func (c *TieredCache) SetMany(ctx context.Context, items map[string]interface{}, ttl time.Duration) error {
+	if err := c.l2.SetMany(ctx, items, ttl); err != nil {
+		return err
+	}
+
+	return c.l1.SetMany(ctx, items, c.l1TTLFor(ttl))
+}
+
+// Increment atomically adds delta to an integer value in L2, see IncrementWithTTL
+func (c *TieredCache) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	return c.IncrementWithTTL(ctx, key, delta, 0)
+}
+
+// IncrementWithTTL atomically adds delta to an integer value in L2 and drops
+// the L1 copy, so counters are never served stale from this node
+func (c *TieredCache) IncrementWithTTL(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	value, err := c.l2.IncrementWithTTL(ctx, key, delta, ttl)
+	if err != nil {
+		return 0, err
+	}
+
+	return value, c.l1.Delete(ctx, key)
+}
+
+// Decrement atomically subtracts delta from an integer value, see Increment
+func (c *TieredCache) Decrement(ctx context.Context, key string, delta int64) (int64, error) {
+	return c.IncrementWithTTL(ctx, key, -delta, 0)
+}
+
+// Delete removes a value from both tiers
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	return errors.Join(c.l2.Delete(ctx, key), c.l1.Delete(ctx, key))
+}
+
+// DeleteByPattern removes matching entries from both tiers
+func (c *TieredCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	return errors.Join(c.l2.DeleteByPattern(ctx, pattern), c.l1.DeleteByPattern(ctx, pattern))
+}
+
+// DeleteByPatternWithOpts removes matching entries from both tiers
+func (c *TieredCache) DeleteByPatternWithOpts(ctx context.Context, pattern string, opts DeleteByPatternOpts) error {
+	return errors.Join(c.l2.DeleteByPatternWithOpts(ctx, pattern, opts), c.l1.DeleteByPatternWithOpts(ctx, pattern, opts))
+}
+
+// Clear removes all entries from both tiers
+func (c *TieredCache) Clear(ctx context.Context) error {
+	return errors.Join(c.l2.Clear(ctx), c.l1.Clear(ctx))
+}
+
+// Keys returns the L2 keys that match pattern
+func (c *TieredCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	return c.l2.Keys(ctx, pattern)
+}
+
+// Len returns the number of entries in L2
+func (c *TieredCache) Len(ctx context.Context) int {
+	return c.l2.Len(ctx)
+}
+
+// Namespaced returns a view of the cache that prefixes keys with "<namespace>:"
+func (c *TieredCache) Namespaced(namespace string) Cache {
+	return NewNamespacedCache(c, namespace)
+}
+
+// PurgeExpired removes the expired entries of both tiers and returns the total
+func (c *TieredCache) PurgeExpired(ctx context.Context) (int, error) {
+	l1, err1 := c.l1.PurgeExpired(ctx)
+	l2, err2 := c.l2.PurgeExpired(ctx)
+	return l1 + l2, errors.Join(err1, err2)
+}
+
+// Stats returns combined statistics: a hit in either tier counts as a hit,
+// Size is the L2 size and Expired the sum of both tiers. Each tier keeps its
+// own Stats as well.
+func (c *TieredCache) Stats() Stats {
+	hits, misses := c.stats.snapshot()
+	l2 := c.l2.Stats()
+	return Stats{
+		Hits:    hits,
+		Misses:  misses,
+		Size:    l2.Size,
+		Expired: c.l1.Stats().Expired + l2.Expired,
+	}
+}
+
+// ResetStats resets the combined hit and miss counters, leaving the tiers'
+// own stats, Expired included, intact
+func (c *TieredCache) ResetStats(ctx context.Context) {
+	c.stats.reset()
+}
+
+// Close closes both tiers
+func (c *TieredCache) Close() error {
+	return errors.Join(c.l1.Close(), c.l2.Close())
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// TestTieredCacheL1FillKeepsL2Expiry checks that L2 hits copied into L1, by
+// Get and by GetMany, expire from L1 no later than from L2
+func TestTieredCacheL1FillKeepsL2Expiry(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	l2s := map[string]func() Cache{
+		"lru": func() Cache { return NewLRUCache(100) },
+		"redis": func() Cache {
+			mr.FlushAll()
+			return NewRedisCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+		},
+		"namespaced": func() Cache { return NewLRUCache(100).Namespaced("app") },
+	}
+
+	for name, newL2 := range l2s {
+		t.Run(name, func(t *testing.T) {
+			l1 := NewLRUCache(100).(*LRUCache)
+			l2 := newL2()
+			tiered := NewTieredCacheWithConfig(TieredConfig{L1: l1, L2: l2, L1TTL: time.Hour})
+
+			_ = l2.SetWithTTL(ctx, "short", 1, 2*time.Second)
+			_ = l2.SetWithTTL(ctx, "long", 2, 2*time.Hour)
+			_ = l2.Set(ctx, "forever", 3)
+			_ = l2.SetWithTTL(ctx, "many", 4, 2*time.Second)
+			// L2 deadlines are at most two seconds from here
+			stored := time.Now()
+			// miniredis TTLs only count down when told to
+			mr.FastForward(time.Second)
+
+			for _, key := range []string{"short", "long", "forever"} {
+				if _, ok := tiered.Get(ctx, key); !ok {
+					t.Fatalf("Get(%q) missed", key)
+				}
+			}
+			if _, err := tiered.GetMany(ctx, []string{"many"}); err != nil {
+				t.Fatal(err)
+			}
+			filled := time.Now()
+
+			for key, limit := range map[string]time.Time{
+				"short":   stored.Add(2 * time.Second),
+				"many":    stored.Add(2 * time.Second),
+				"long":    filled.Add(time.Hour),
+				"forever": filled.Add(time.Hour),
+			} {
+				l1.mu.RLock()
+				expiresAt, ok := l1.ttlMap[key]
+				l1.mu.RUnlock()
+				if !ok {
+					t.Fatalf("%s wasn't copied into L1 with a TTL", key)
+				}
+				if expiresAt.After(limit) {
+					t.Errorf("%s expires from L1 %v too late", key, expiresAt.Sub(limit))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// errTTLUnsupported is returned by remainingTTLs when the backing cache can't
+// report TTLs
+var errTTLUnsupported = errors.New("cache does not report TTLs")
+
+// ttlReader is implemented by caches that can report how long keys have
+// left, so TieredCache doesn't keep an L1 copy past its L2 expiry
+type ttlReader interface {
+	// remainingTTLs returns the remaining TTL of each key present, zero for
+	// keys without expiration; missing and expired keys are left out
+	remainingTTLs(ctx context.Context, keys []string) (map[string]time.Duration, error)
+}
+
+func (c *LRUCache) remainingTTLs(ctx context.Context, keys []string) (map[string]time.Duration, error) {
+	now := time.Now()
+	ttls := make(map[string]time.Duration, len(keys))
+	for _, key := range keys {
+		item, ok := c.cache.Peek(key)
+		switch {
+		case !ok:
+		case item.expiresAt.IsZero():
+			ttls[key] = 0
+		case now.Before(item.expiresAt):
+			ttls[key] = item.expiresAt.Sub(now)
+		}
+	}
+	return ttls, nil
+}
+
+func (c *RedisCache) remainingTTLs(ctx context.Context, keys []string) (map[string]time.Duration, error) {
+	cmds := make([]*redis.DurationCmd, len(keys))
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.PTTL(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache key TTLs: %w", err)
+	}
+
+	ttls := make(map[string]time.Duration, len(keys))
+	for i, cmd := range cmds {
+		// PTTL reports -1 for no expiration and -2 for a missing key
+		switch ttl := cmd.Val(); {
+		case ttl == -1:
+			ttls[keys[i]] = 0
+		case ttl > 0:
+			ttls[keys[i]] = ttl
+		}
+	}
+	return ttls, nil
+}
+
+func (c *NamespacedCache) remainingTTLs(ctx context.Context, keys []string) (map[string]time.Duration, error) {
+	backend, ok := c.cache.(ttlReader)
+	if !ok {
+		return nil, errTTLUnsupported
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+
+	found, err := backend.remainingTTLs(ctx, prefixed)
+	if err != nil {
+		return nil, err
+	}
+
+	ttls := make(map[string]time.Duration, len(found))
+	for key, ttl := range found {
+		ttls[strings.TrimPrefix(key, c.prefix)] = ttl
+	}
+	return ttls, nil
+}
+
+func (c *TieredCache) remainingTTLs(ctx context.Context, keys []string) (map[string]time.Duration, error) {
+	backend, ok := c.l2.(ttlReader)
+	if !ok {
+		return nil, errTTLUnsupported
+	}
+	return backend.remainingTTLs(ctx, keys)
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pengenjago/fibox/logging"
+)
+
+// TypedCache wraps a Cache and exposes type-safe accessors for values of type T.
+// Backends that serialize values (such as RedisCache) hand back decoded JSON
+// types, so struct types only round-trip through an LRUCache, with no Codec
+// or with a TypedCodec such as JSONCodec.
+type TypedCache[T any] struct {
+	cache Cache
+}
+
+// NewTypedCache creates a new typed view over the given cache
+func NewTypedCache[T any](cache Cache) *TypedCache[T] {
+	return &TypedCache[T]{
+		cache: cache,
+	}
+}
+
+// Get retrieves a value from the cache.
+// It returns the zero value and false on a miss or when the stored value is not a T.
+func (c *TypedCache[T]) Get(ctx context.Context, key string) (T, bool) {
+	value, ok, err := c.Lookup(ctx, key)
+	if err != nil {
+		logging.WarnWithFields("Cache value type mismatch",
+			map[string]interface{}{
+				"key":   key,
+				"error": err.Error(),
+			})
+	}
+	return value, ok
+}
+
+// Lookup retrieves a value from the cache and reports a descriptive error
+// when the stored value is not a T
+func (c *TypedCache[T]) Lookup(ctx context.Context, key string) (T, bool, error) {
+	var zero T
+
+	raw, ok := c.cache.Get(ctx, key)
+	if !ok {
+		return zero, false, nil
+	}
+
+	value, ok := raw.(T)
+	if !ok {
+		return zero, false, fmt.Errorf("cache value for key %s has type %T, expected %T", key, raw, zero)
+	}
+
+	return value, true, nil
+}
+
+// GetOrSet returns the cached value for key, or invokes loader on a miss and
+// stores its result with the given TTL (zero means no expiration)
+func (c *TypedCache[T]) GetOrSet(ctx context.Context, key string, ttl time.Duration, loader func() (T, error)) (T, error) {
+	var zero T
+
+	raw, err := c.cache.GetOrSet(ctx, key, ttl, func() (interface{}, error) {
+		return loader()
+	})
+	if err != nil {
+		return zero, err
+	}
+
+	value, ok := raw.(T)
+	if !ok {
+		return zero, fmt.Errorf("cache value for key %s has type %T, expected %T", key, raw, zero)
+	}
+
+	return value, nil
+}
+
+// Set stores a value in the cache without TTL
+func (c *TypedCache[T]) Set(ctx context.Context, key string, value T) error {
+	return c.cache.Set(ctx, key, value)
+}
+
+// SetWithTTL stores a value in the cache with a TTL
+func (c *TypedCache[T]) SetWithTTL(ctx context.Context, key string, value T, ttl time.Duration) error {
+	return c.cache.SetWithTTL(ctx, key, value, ttl)
+}
+
+// Delete removes a value from the cache
+func (c *TypedCache[T]) Delete(ctx context.Context, key string) error {
+	return c.cache.Delete(ctx, key)
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type typedUser struct {
+	Name  string
+	Roles []string
+	Age   int
+}
+
+// TestTypedCacheOverLRUCodec checks that an LRU cache with JSONCodec hands
+// TypedCache back the stored type, as a copy
+func TestTypedCacheOverLRUCodec(t *testing.T) {
+	ctx := context.Background()
+	lru := NewLRUCacheWithConfig(LRUConfig{Size: 10, Codec: JSONCodec{}})
+
+	users := NewTypedCache[typedUser](lru)
+	if err := users.Set(ctx, "user:1", typedUser{Name: "Ana", Roles: []string{"admin"}, Age: 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok, err := users.Lookup(ctx, "user:1")
+	if err != nil || !ok {
+		t.Fatalf("Lookup = %v, %v", ok, err)
+	}
+	if got.Name != "Ana" || got.Age != 30 || len(got.Roles) != 1 {
+		t.Fatalf("Lookup = %+v", got)
+	}
+
+	got.Roles[0] = "guest"
+	if again, _ := users.Get(ctx, "user:1"); again.Roles[0] != "admin" {
+		t.Fatal("modifying a returned value changed the cached entry")
+	}
+
+	loaded, err := users.GetOrSet(ctx, "user:2", time.Minute, func() (typedUser, error) {
+		return typedUser{Name: "Budi"}, nil
+	})
+	if err != nil || loaded.Name != "Budi" {
+		t.Fatalf("GetOrSet = %+v, %v", loaded, err)
+	}
+	if cached, ok := users.Get(ctx, "user:2"); !ok || cached.Name != "Budi" {
+		t.Fatalf("Get after GetOrSet = %+v, %v", cached, ok)
+	}
+
+	pointers := NewTypedCache[*typedUser](lru)
+	_ = pointers.Set(ctx, "ptr", &typedUser{Name: "Citra"})
+	if p, ok := pointers.Get(ctx, "ptr"); !ok || p.Name != "Citra" {
+		t.Fatalf("Get pointer = %+v, %v", p, ok)
+	}
+
+	// Counters still work on typed values
+	if n, err := lru.Increment(ctx, "count", 2); err != nil || n != 2 {
+		t.Fatalf("Increment = %d, %v", n, err)
+	}
+	if n, err := lru.Increment(ctx, "count", 3); err != nil || n != 5 {
+		t.Fatalf("Increment = %d, %v", n, err)
+	}
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pengenjago/fibox/logging"
+)
+
+// Warm pre-populates c with the entries returned by loader, e.g. hot keys at
+// startup, so the first requests don't all miss. Entries are stored with one
+// SetMany call and the given TTL (zero means no expiration), subject to the
+// cache's TTLJitter.
+//
+// Warm may run concurrently with normal traffic: entries are written like
+// regular sets, so a key written by a request in the meantime is overwritten
+// with the loaded value. On an LRU cache smaller than the warm set, later
+// entries evict earlier ones, and since map order is random, which entries
+// survive is arbitrary; warm only as many keys as the cache holds.
+func Warm(ctx context.Context, c Cache, loader func() (map[string]interface{}, error), ttl time.Duration) error {
+	start := time.Now()
+
+	entries, err := loader()
+	if err != nil {
+		return fmt.Errorf("failed to load cache warm entries: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// The loader may have been slow; don't write for a caller that gave up
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := c.SetMany(ctx, entries, ttl); err != nil {
+		return fmt.Errorf("failed to warm cache: %w", err)
+	}
+
+	logging.InfoWithFields("Cache warmed",
+		map[string]interface{}{
+			"count":   len(entries),
+			"elapsed": time.Since(start).String(),
+		})
+	return nil
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker fast-fails calls after consecutive failures.
+// After OpenDuration it lets up to halfOpenMax trial calls through; a trial
+// success closes the circuit again and a trial failure re-opens it.
+type circuitBreaker struct {
+	mu            sync.Mutex
+	state         breakerState
+	failures      int
+	openedAt      time.Time
+	halfOpenCalls int
+	threshold     int
+	openDuration  time.Duration
+	halfOpenMax   int
+}
+
+func newCircuitBreaker(threshold int, openDuration time.Duration, halfOpenMax int) *circuitBreaker {
+	if openDuration == 0 {
+		openDuration = 30 * time.Second
+	}
+	if halfOpenMax <= 0 {
+		halfOpenMax = 1
+	}
+
+	return &circuitBreaker{
+		threshold:    threshold,
+		openDuration: openDuration,
+		halfOpenMax:  halfOpenMax,
+	}
+}
+
+// allow reports whether a call may proceed, returning a CircuitOpenError if not
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		remaining := b.openDuration - time.Since(b.openedAt)
+		if remaining > 0 {
+			return &CircuitOpenError{RetryAfter: remaining}
+		}
+		b.state = breakerHalfOpen
+		b.halfOpenCalls = 0
+		fallthrough
+	case breakerHalfOpen:
+		if b.halfOpenCalls >= b.halfOpenMax {
+			return &CircuitOpenError{}
+		}
+		b.halfOpenCalls++
+	}
+
+	return nil
+}
+
+// success records a successful call
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = breakerClosed
+	b.failures = 0
+	b.halfOpenCalls = 0
+}
+
+// failure records a failed call and opens the circuit when the threshold is reached
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+		b.halfOpenCalls = 0
+	}
+}
+
+// release records a call that neither succeeded nor failed, such as one
+// cancelled by the caller, freeing its half-open slot
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen && b.halfOpenCalls > 0 {
+		b.halfOpenCalls--
+	}
+}
@@ -0,0 +1,189 @@
+// Package clienttest stubs HTTPClient responses in-process, so code using
+// client.HTTPClient can be tested without starting an httptest server.
+// Responses are registered per method and path; every request is recorded
+// for assertions.
+package clienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/pengenjago/fibox/client"
+)
+
+// DefaultBaseURL is used by NewMockHTTPClient when the config has no BaseURL
+const DefaultBaseURL = "http://clienttest.invalid"
+
+// Response is a canned response. A non-nil Err fails the request at the
+// transport level instead, e.g. to test retries or fallbacks.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Err        error
+}
+
+// JSON returns a response with body encoded as JSON
+func JSON(status int, body interface{}) Response {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return Response{Err: fmt.Errorf("clienttest: failed to encode JSON response: %w", err)}
+	}
+
+	return Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       data,
+	}
+}
+
+// Request is a request received by a MockTransport
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// MockTransport is an http.RoundTripper answering with canned responses.
+// It is safe for concurrent use.
+type MockTransport struct {
+	mu        sync.Mutex
+	responses map[string][]Response
+	requests  []Request
+}
+
+// NewMockTransport creates a transport with no responses registered
+func NewMockTransport() *MockTransport {
+	return &MockTransport{
+		responses: make(map[string][]Response),
+	}
+}
+
+// On queues responses for requests matching method and path (without the
+// query string). They are returned in order and the last one repeats.
+// Requests without a registered response fail with an error.
+func (m *MockTransport) On(method, path string, responses ...Response) *MockTransport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := method + " " + path
+	m.responses[key] = append(m.responses[key], responses...)
+	return m
+}
+
+// Requests returns the requests received so far, in order
+func (m *MockTransport) Requests() []Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Request(nil), m.requests...)
+}
+
+// LastRequest returns the most recent request, false if none was received
+func (m *MockTransport) LastRequest() (Request, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.requests) == 0 {
+		return Request{}, false
+	}
+	return m.requests[len(m.requests)-1], true
+}
+
+// Reset drops the registered responses and recorded requests
+func (m *MockTransport) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.responses = make(map[string][]Response)
+	m.requests = nil
+}
+
+// RoundTrip records req and answers with the next registered response
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	m.mu.Lock()
+	m.requests = append(m.requests, Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query(),
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+
+	key := req.Method + " " + req.URL.Path
+	queue := m.responses[key]
+	if len(queue) > 1 {
+		m.responses[key] = queue[1:]
+	}
+	m.mu.Unlock()
+
+	if len(queue) == 0 {
+		return nil, fmt.Errorf("clienttest: no response registered for %s", key)
+	}
+
+	resp := queue[0]
+	if resp.Err != nil {
+		return nil, resp.Err
+	}
+
+	status := resp.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := resp.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}, nil
+}
+
+// MockHTTPClient is an HTTPClient backed by a MockTransport: register
+// responses with On and inspect what was sent with Requests
+type MockHTTPClient struct {
+	*client.HTTPClient
+	*MockTransport
+}
+
+// NewMockHTTPClient creates an HTTPClient from config whose requests are
+// answered by a new MockTransport. An empty BaseURL defaults to DefaultBaseURL.
+func NewMockHTTPClient(config client.HTTPClientConfig) *MockHTTPClient {
+	transport := NewMockTransport()
+
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	config.Transport = transport
+
+	return &MockHTTPClient{
+		HTTPClient:    client.NewHTTPClient(config),
+		MockTransport: transport,
+	}
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// executeGet sends a GET request and decodes the response into result. With
+// CoalesceGets, identical GETs in flight at the same time share one request:
+// the first caller sends it and every caller decodes the shared body.
+func (c *HTTPClient) executeGet(ctx context.Context, req *resty.Request, path, label string, result interface{}) (*resty.Response, error) {
+	if !c.coalesceGets {
+		req.SetResult(result)
+		return c.execute(ctx, req, resty.MethodGet, path, label)
+	}
+
+	// The shared request outlives any single caller giving up, bounded by the
+	// client Timeout; each caller only stops waiting for it
+	shared := context.WithoutCancel(ctx)
+	ch := c.getGroup.DoChan(coalesceKey(req, path), func() (interface{}, error) {
+		return c.execute(shared, req, resty.MethodGet, path, label)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("HTTP %s request %s cancelled: %w", label, path, ctx.Err())
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		resp := res.Val.(*resty.Response)
+		if err := decodeResult(resp, result); err != nil {
+			return nil, fmt.Errorf("failed to decode HTTP %s response %s: %w", label, path, err)
+		}
+		return resp, nil
+	}
+}
+
+// coalesceKey identifies a GET by everything set on the request itself: the
+// path, path and query params, headers and token. Client-level settings are
+// the same for every request, so they don't need to be part of it.
+func coalesceKey(req *resty.Request, path string) string {
+	var key strings.Builder
+	key.WriteString(path)
+
+	key.WriteString("\n")
+	for _, params := range []map[string]string{req.PathParams, req.RawPathParams} {
+		names := make([]string, 0, len(params))
+		for name := range params {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			key.WriteString(name + "=" + params[name] + "&")
+		}
+		key.WriteString("\n")
+	}
+
+	key.WriteString(req.QueryParam.Encode())
+
+	key.WriteString("\n")
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		key.WriteString(name + ":" + strings.Join(req.Header[name], ",") + "\n")
+	}
+
+	key.WriteString(req.Token)
+	return key.String()
+}
+
+// decodeResult decodes a JSON or XML response body into result, as resty
+// does for a request's own Result
+func decodeResult(resp *resty.Response, result interface{}) error {
+	if result == nil || len(resp.Body()) == 0 {
+		return nil
+	}
+
+	contentType := resp.Header().Get("Content-Type")
+	switch {
+	case resty.IsJSONType(contentType):
+		return json.Unmarshal(resp.Body(), result)
+	case resty.IsXMLType(contentType):
+		return xml.Unmarshal(resp.Body(), result)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesceGets(t *testing.T) {
+	const callers = 20
+
+	for _, tt := range []struct {
+		name     string
+		coalesce bool
+		hits     int32
+	}{
+		{"enabled", true, 1},
+		{"disabled", false, callers},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hits.Add(1)
+				// Keep the request in flight until every caller has joined
+				time.Sleep(200 * time.Millisecond)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"value":"` + r.URL.Query().Get("key") + `"}`))
+			}))
+			defer srv.Close()
+
+			c := NewHTTPClient(HTTPClientConfig{BaseURL: srv.URL, CoalesceGets: tt.coalesce})
+
+			var wg sync.WaitGroup
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					var result struct{ Value string }
+					err := c.GetCtx(context.Background(), "/config", map[string]string{"key": "flags"}, &result)
+					if err != nil || result.Value != "flags" {
+						t.Errorf("GetCtx = %+v, %v; want flags", result, err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := hits.Load(); got != tt.hits {
+				t.Fatalf("upstream hits = %d, want %d", got, tt.hits)
+			}
+		})
+	}
+}
+
+func TestCoalesceGetsKeepsDistinctRequestsApart(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"value":"` + r.URL.Query().Get("key") + `"}`))
+	}))
+	defer srv.Close()
+
+	c := NewHTTPClient(HTTPClientConfig{BaseURL: srv.URL, CoalesceGets: true})
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b", "a", "b"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var result struct{ Value string }
+			err := c.GetCtx(context.Background(), "/config", map[string]string{"key": key}, &result)
+			if err != nil || result.Value != key {
+				t.Errorf("GetCtx = %+v, %v; want %s", result, err, key)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := hits.Load(); got != 2 {
+		t.Fatalf("upstream hits = %d, want 2", got)
+	}
+}
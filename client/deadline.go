@@ -0,0 +1,48 @@
+package client
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// headerGRPCTimeout carries the remaining time budget, see middleware.NewDeadline
+const headerGRPCTimeout = "Grpc-Timeout"
+
+// propagateDeadline sets the grpc-timeout header from the request context's
+// deadline. It runs per attempt, so retries send what's left of the budget.
+func propagateDeadline(_ *resty.Client, req *resty.Request) error {
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return nil
+	}
+
+	if remaining := time.Until(deadline); remaining > 0 {
+		req.SetHeader(headerGRPCTimeout, formatGRPCTimeout(remaining))
+	}
+	return nil
+}
+
+// grpcTimeoutUnits are tried in order until the value fits grpc-timeout's
+// 8-digit limit
+var grpcTimeoutUnits = []struct {
+	suffix string
+	unit   time.Duration
+}{
+	{"m", time.Millisecond},
+	{"S", time.Second},
+	{"M", time.Minute},
+	{"H", time.Hour},
+}
+
+// formatGRPCTimeout encodes d as a grpc-timeout value, truncated so the
+// budget is never overstated
+func formatGRPCTimeout(d time.Duration) string {
+	for _, u := range grpcTimeoutUnits {
+		if value := d / u.unit; value <= 99999999 {
+			return strconv.FormatInt(int64(value), 10) + u.suffix
+		}
+	}
+	return "99999999H"
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUpstreamError matches, via errors.Is, the HTTPError of a call that
+// decoded the upstream's error response into the caller's error result, such
+// as PostExpect
+var ErrUpstreamError = errors.New("upstream returned an error response")
+
+// HTTPError is returned when the server responds with a non-2xx status.
+// Use errors.As to inspect the status code and body.
+type HTTPError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Body       []byte
+	// ErrorResult is the caller's error result the body was decoded into,
+	// nil unless one was given
+	ErrorResult interface{}
+}
+
+// Error returns a human-readable description of the failed request
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %s request %s returned error status: %d, body: %s", e.Method, e.Path, e.StatusCode, e.Body)
+}
+
+// Is reports whether target is ErrUpstreamError and the body was decoded
+// into an error result
+func (e *HTTPError) Is(target error) bool {
+	return target == ErrUpstreamError && e.ErrorResult != nil
+}
+
+// CircuitOpenError is returned without contacting the server while the
+// client's circuit breaker is open
+type CircuitOpenError struct {
+	// RetryAfter is the remaining time before trial calls are allowed,
+	// zero when the circuit is half-open and all trial slots are taken
+	RetryAfter time.Duration
+}
+
+// Error returns a human-readable description of the breaker state
+func (e *CircuitOpenError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("circuit breaker is open, retry after %s", e.RetryAfter)
+	}
+	return "circuit breaker is open"
+}
+
+// GraphQLErrorDetail is one entry of a GraphQL response's errors array
+type GraphQLErrorDetail struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Locations  []GraphQLLocation      `json:"locations,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLLocation points at the part of the query an error refers to
+type GraphQLLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// GraphQLError is returned when a GraphQL response carries a non-empty errors
+// array, which servers usually send with a 200 status.
+// Use errors.As to inspect the individual errors.
+type GraphQLError struct {
+	Path   string
+	Errors []GraphQLErrorDetail
+}
+
+// Error returns the first error message and the number of errors
+func (e *GraphQLError) Error() string {
+	if len(e.Errors) == 1 {
+		return fmt.Sprintf("GraphQL request %s returned an error: %s", e.Path, e.Errors[0].Message)
+	}
+	return fmt.Sprintf("GraphQL request %s returned %d errors, first: %s", e.Path, len(e.Errors), e.Errors[0].Message)
+}
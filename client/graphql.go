@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// graphQLRequest is the standard GraphQL request envelope
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// graphQLResponse is the standard GraphQL response envelope
+type graphQLResponse struct {
+	Data   json.RawMessage      `json:"data"`
+	Errors []GraphQLErrorDetail `json:"errors"`
+}
+
+// PostGraphQL sends query and variables to a GraphQL endpoint and decodes the
+// response's data field into result
+func (c *HTTPClient) PostGraphQL(path, query string, variables map[string]interface{}, result interface{}) error {
+	return c.PostGraphQLCtx(context.Background(), path, query, variables, result)
+}
+
+// PostGraphQLCtx sends a GraphQL query bound to the given context. A response
+// with a non-empty errors array returns a *GraphQLError even with a 200
+// status; any partial data is still decoded into result.
+func (c *HTTPClient) PostGraphQLCtx(ctx context.Context, path, query string, variables map[string]interface{}, result interface{}) error {
+	var response graphQLResponse
+
+	req := c.client.R().
+		SetHeader("Content-Type", "application/json").
+		SetBody(graphQLRequest{Query: query, Variables: variables}).
+		SetResult(&response)
+
+	if _, err := c.execute(ctx, req, resty.MethodPost, path, "POST GraphQL"); err != nil {
+		return err
+	}
+
+	if result != nil && len(response.Data) > 0 && string(response.Data) != "null" {
+		if err := json.Unmarshal(response.Data, result); err != nil {
+			return fmt.Errorf("failed to decode GraphQL data from %s: %w", path, err)
+		}
+	}
+
+	if len(response.Errors) > 0 {
+		return &GraphQLError{Path: path, Errors: response.Errors}
+	}
+
+	return nil
+}
@@ -1,14 +1,31 @@
 package client
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/gofiber/fiber/v3/log"
+	"golang.org/x/net/publicsuffix"
+	"golang.org/x/sync/singleflight"
 )
 
+// maxErrorBodySize bounds how much of a streamed error response is read into HTTPError
+const maxErrorBodySize = 64 << 10
+
 // HTTPClientConfig is configuration for HTTP client
 type HTTPClientConfig struct {
 	BaseURL          string
@@ -18,11 +35,156 @@ type HTTPClientConfig struct {
 	RetryWaitTime    time.Duration
 	RetryMaxWaitTime time.Duration
 	Debug            bool
+
+	// RetryConditions decide whether a completed response should be retried;
+	// a response is retried when any condition returns true. Transport errors
+	// are always retried. Retries are capped by RetryCount, so without one
+	// the conditions never fire. A 429/503 Retry-After header overrides the backoff,
+	// clamped between RetryWaitTime and RetryMaxWaitTime.
+	// RequestOptions.RetryCount lowers RetryCount for a single call. Form
+	// requests only retry when they opt in, and multipart requests never do.
+	RetryConditions []func(statusCode int, body []byte) bool
+
+	// DefaultContentType is sent on requests that don't set their own
+	// Content-Type, default application/json. It is skipped when
+	// Headers already contains a Content-Type.
+	DefaultContentType string
+	// DisableDefaultContentType sends no client-wide Content-Type at all,
+	// letting each request's body determine it
+	DisableDefaultContentType bool
+
+	// DisableCompression stops requesting gzip responses, e.g. to read raw
+	// traffic while debugging. By default the transport sends
+	// Accept-Encoding: gzip and transparently decompresses the response, so
+	// results and GetStream readers are always plain. A request that sets its
+	// own Accept-Encoding header opts out of transparent decompression.
+	DisableCompression bool
+
+	// MaxIdleConns bounds idle keep-alive connections across all hosts,
+	// default 200
+	MaxIdleConns int
+	// MaxIdleConnsPerHost bounds idle keep-alive connections per host, default
+	// 100. net/http's default of 2 forces new connections when many requests
+	// go to one upstream at once.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost bounds all connections per host, dialing, active and
+	// idle; requests beyond it wait for a free connection. Zero means no limit.
+	MaxConnsPerHost int
+	// IdleConnTimeout closes keep-alive connections idle for this long,
+	// default 90 seconds
+	IdleConnTimeout time.Duration
+
+	// CoalesceGets makes concurrent identical GETs (same path, params, query
+	// and per-request headers) share one in-flight request, e.g. many
+	// goroutines fetching the same config at once. A caller that gives up
+	// stops waiting without cancelling the shared request, which is bounded
+	// by Timeout instead. Disabled by default.
+	CoalesceGets bool
+
+	// Transport replaces the HTTP transport, e.g. a clienttest.MockTransport
+	// to stub responses in tests. The pool, proxy, TLS and compression
+	// settings above only apply to the default transport.
+	Transport http.RoundTripper
+
+	// EnableCookieJar stores cookies set by responses and sends them on later
+	// requests, e.g. for upstream APIs that use a login session. It makes the
+	// client stateful, so a client with a jar must not be shared across
+	// unrelated users. Disabled by default.
+	EnableCookieJar bool
+
+	// MaxRedirects bounds how many redirects are followed, default 10
+	MaxRedirects int
+	// DisableRedirects returns 3xx responses as-is instead of following them
+	DisableRedirects bool
+
+	// ProxyURL routes all requests through the given proxy, e.g.
+	// "http://proxy.corp:3128". Empty uses the HTTP_PROXY/HTTPS_PROXY environment.
+	ProxyURL string
+	// InsecureSkipVerify disables TLS certificate verification. It exposes
+	// traffic to interception and is only meant for internal self-signed
+	// endpoints during development; a warning is logged when it's enabled.
+	InsecureSkipVerify bool
+	// RootCAFile is a PEM file of CA certificates trusted instead of the
+	// system roots, e.g. an internal CA
+	RootCAFile string
+	// ClientCertFile and ClientKeyFile are PEM files of the client
+	// certificate and key presented for mutual TLS
+	ClientCertFile string
+	ClientKeyFile  string
+
+	// PropagateDeadline sends the remaining time of the request context's
+	// deadline as a grpc-timeout header (e.g. "1500m"), so a downstream
+	// service using middleware.NewDeadline stops when the caller gives up
+	PropagateDeadline bool
+
+	// BeforeRequest hooks run before every request is sent
+	BeforeRequest []func(*resty.Request)
+	// AfterResponse hooks run after every response is received, including error statuses
+	AfterResponse []func(*resty.Response)
+	// RefreshToken is called after a 401 response to obtain a new bearer token;
+	// the request is then replayed once with it. Concurrent 401s share one refresh.
+	// Requests with a body that can't be rewound, such as multipart uploads,
+	// aren't replayed.
+	RefreshToken func(ctx context.Context) (string, error)
+	// OAuth2 fetches, caches and refreshes client-credentials bearer tokens.
+	// It takes precedence over RefreshToken.
+	OAuth2 *OAuth2Config
+
+	// Metrics receives every request's method, path, status and duration
+	Metrics MetricsCollector
+	// MetricsPath maps request paths before they're reported to Metrics,
+	// default TemplatePath
+	MetricsPath func(path string) string
+
+	// FailureThreshold enables the circuit breaker: after this many consecutive
+	// failures (transport errors or 5xx) calls fail fast with CircuitOpenError.
+	// Zero disables the breaker.
+	FailureThreshold int
+	// OpenDuration is how long the circuit stays open before trial calls are
+	// allowed, default 30 seconds
+	OpenDuration time.Duration
+	// HalfOpenMaxCalls is the number of concurrent trial calls allowed while
+	// half-open, default 1
+	HalfOpenMaxCalls int
+
+	// Fallback is called when a request fails after all retries, including
+	// error statuses and an open circuit. Returning handled=true makes the
+	// request succeed with result as the response body, e.g. a last-known-good
+	// value from a Cache; otherwise the original error is returned. It isn't
+	// called when the caller's context is cancelled or past its deadline.
+	Fallback func(path string, err error) (result []byte, handled bool)
 }
 
 // HTTPClient is a wrapper for resty client
 type HTTPClient struct {
-	client *resty.Client
+	client       *resty.Client
+	breaker      *circuitBreaker
+	refreshToken func(ctx context.Context) (string, error)
+	oauth2       *oauth2Source
+	fallback     func(path string, err error) ([]byte, bool)
+	coalesceGets bool
+	getGroup     singleflight.Group
+	refreshGroup singleflight.Group
+	tokenMu      sync.RWMutex
+	token        string
+}
+
+// RequestOptions holds per-request settings merged over the client defaults
+type RequestOptions struct {
+	// Headers are added to, or override, the client headers for this request only
+	Headers map[string]string
+	// QueryParams are added to, or override, the client query params for this request only
+	QueryParams map[string]string
+	// PathParams replace {name} placeholders in the request path
+	PathParams map[string]string
+	// Timeout bounds this request only, via its context deadline.
+	// It can shorten but not extend the client Timeout, which still applies.
+	Timeout time.Duration
+	// RetryCount overrides the client RetryCount for this request only, e.g.
+	// RetryCount(0) for a non-idempotent POST that must never be sent twice.
+	// It can lower but not raise the client RetryCount. Nil keeps the client
+	// default, except for form requests, which default to no retry.
+	RetryCount *int
 }
 
 // NewHTTPClient creates a new HTTP client with the given configuration
@@ -46,23 +208,35 @@ func NewHTTPClient(config HTTPClientConfig) *HTTPClient {
 		client = client.SetHeaders(config.Headers)
 	}
 
-	// Set retry count if provided
+	// Set retry count if provided. The backoff, Retry-After handling and
+	// conditions are set up regardless, so they never depend on it.
 	if config.RetryCount > 0 {
 		client = client.SetRetryCount(config.RetryCount)
+	}
 
-		// Set retry wait time if provided, otherwise use default 1 second
-		retryWaitTime := config.RetryWaitTime
-		if retryWaitTime == 0 {
-			retryWaitTime = 1 * time.Second
-		}
-		client = client.SetRetryWaitTime(retryWaitTime)
+	// Set retry wait time if provided, otherwise use default 1 second
+	retryWaitTime := config.RetryWaitTime
+	if retryWaitTime == 0 {
+		retryWaitTime = 1 * time.Second
+	}
+	client = client.SetRetryWaitTime(retryWaitTime)
 
-		// Set retry max wait time if provided, otherwise use default 30 seconds
-		retryMaxWaitTime := config.RetryMaxWaitTime
-		if retryMaxWaitTime == 0 {
-			retryMaxWaitTime = 30 * time.Second
-		}
-		client = client.SetRetryMaxWaitTime(retryMaxWaitTime)
+	// Set retry max wait time if provided, otherwise use default 30 seconds
+	retryMaxWaitTime := config.RetryMaxWaitTime
+	if retryMaxWaitTime == 0 {
+		retryMaxWaitTime = 30 * time.Second
+	}
+	client = client.SetRetryMaxWaitTime(retryMaxWaitTime)
+
+	// Honor server-requested delays
+	client = client.SetRetryAfter(retryAfter)
+
+	// Add custom retry conditions if provided
+	for _, condition := range config.RetryConditions {
+		client = client.AddRetryCondition(retryCondition(condition))
+	}
+	if len(config.RetryConditions) > 0 && config.RetryCount <= 0 {
+		log.Warnf("RetryConditions for HTTP client %q have no effect without a positive RetryCount", config.BaseURL)
 	}
 
 	// Enable debug mode if requested
@@ -70,128 +244,472 @@ func NewHTTPClient(config HTTPClientConfig) *HTTPClient {
 		client = client.SetDebug(true)
 	}
 
-	// Set default JSON content type
-	client = client.SetHeader("Content-Type", "application/json")
+	// Set default content type unless disabled or already provided in headers
+	if !config.DisableDefaultContentType && !hasHeader(config.Headers, "Content-Type") {
+		contentType := config.DefaultContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		client = client.SetHeader("Content-Type", contentType)
+	}
+
+	// resty keeps a cookie jar by default; only stateful clients opt into one
+	if !config.EnableCookieJar {
+		client = client.SetCookieJar(nil)
+	}
+
+	if config.ProxyURL != "" {
+		client = client.SetProxy(config.ProxyURL)
+	}
+
+	configureTLS(client, config)
+
+	client = client.SetRedirectPolicy(redirectPolicy(config.MaxRedirects, config.DisableRedirects))
+
+	if transport, err := client.Transport(); err == nil {
+		configurePool(transport, config)
+		transport.DisableCompression = config.DisableCompression
+	}
 
-	return &HTTPClient{
-		client: client,
+	if config.Transport != nil {
+		client = client.SetTransport(config.Transport)
+	}
+
+	httpClient := &HTTPClient{
+		client:       client,
+		fallback:     config.Fallback,
+		coalesceGets: config.CoalesceGets,
+	}
+
+	// Register hooks if provided
+	for _, hook := range config.BeforeRequest {
+		httpClient.OnBeforeRequest(hook)
+	}
+	for _, hook := range config.AfterResponse {
+		httpClient.OnAfterResponse(hook)
 	}
+
+	// Inject refreshed tokens per request, since mutating the shared client isn't race-free
+	if config.OAuth2 != nil {
+		httpClient.oauth2 = newOAuth2Source(*config.OAuth2, client.GetClient())
+		httpClient.refreshToken = httpClient.oauth2.refresh
+		httpClient.client.OnBeforeRequest(httpClient.injectOAuth2Token)
+	} else if config.RefreshToken != nil {
+		httpClient.refreshToken = config.RefreshToken
+		httpClient.client.OnBeforeRequest(httpClient.injectToken)
+	}
+
+	if config.PropagateDeadline {
+		httpClient.client.OnBeforeRequest(propagateDeadline)
+	}
+
+	// Report request metrics if a collector is provided
+	if config.Metrics != nil {
+		httpClient.SetMetricsCollector(config.Metrics, config.MetricsPath)
+	}
+
+	// Enable circuit breaker if requested
+	if config.FailureThreshold > 0 {
+		httpClient.breaker = newCircuitBreaker(config.FailureThreshold, config.OpenDuration, config.HalfOpenMaxCalls)
+	}
+
+	if config.EnableCookieJar {
+		httpClient.EnableCookieJar()
+	}
+
+	return httpClient
 }
 
-// Get performs a GET request
-func (c *HTTPClient) Get(path string, queryParams map[string]string, result interface{}) error {
-	resp, err := c.client.R().
-		SetQueryParams(queryParams).
-		SetResult(result).
-		Get(path)
+// defaultMaxRedirects matches net/http's own limit
+const defaultMaxRedirects = 10
 
-	if err != nil {
-		log.Errorf("HTTP GET request failed: %v", err)
-		return fmt.Errorf("HTTP GET request failed: %w", err)
+// redirectPolicy follows up to maxRedirects redirects, dropping credentials
+// whenever a hop leaves the original host
+func redirectPolicy(maxRedirects int, disabled bool) resty.RedirectPolicy {
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
 	}
 
-	if resp.IsError() {
-		log.Errorf("HTTP GET request returned error status: %d, body: %s", resp.StatusCode(), resp.Body())
-		return fmt.Errorf("HTTP GET request returned error status: %d, body: %s", resp.StatusCode(), resp.Body())
+	return resty.RedirectPolicyFunc(func(req *http.Request, via []*http.Request) error {
+		if disabled {
+			return http.ErrUseLastResponse
+		}
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+
+		// net/http keeps credentials for subdomains; only the exact host is trusted here
+		if !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+			req.Header.Del("Authorization")
+			req.Header.Del("Proxy-Authorization")
+			req.Header.Del("Cookie")
+		}
+		return nil
+	})
+}
+
+// Connection pool defaults, sized for services that call one upstream heavily
+const (
+	defaultMaxIdleConns        = 200
+	defaultMaxIdleConnsPerHost = 100
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// configurePool applies the connection pool settings of config to transport
+func configurePool(transport *http.Transport, config HTTPClientConfig) {
+	transport.MaxIdleConns = defaultMaxIdleConns
+	if config.MaxIdleConns > 0 {
+		transport.MaxIdleConns = config.MaxIdleConns
 	}
 
-	return nil
+	transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	if config.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	}
+
+	transport.MaxConnsPerHost = config.MaxConnsPerHost
+
+	transport.IdleConnTimeout = defaultIdleConnTimeout
+	if config.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = config.IdleConnTimeout
+	}
+}
+
+// configureTLS applies the TLS settings of config to client. Certificate
+// files that can't be loaded are logged, leaving the default behavior in place.
+func configureTLS(client *resty.Client, config HTTPClientConfig) {
+	if config.InsecureSkipVerify {
+		log.Warnf("TLS certificate verification is DISABLED for HTTP client %q: connections can be intercepted, never use InsecureSkipVerify in production", config.BaseURL)
+		client.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true})
+	}
+
+	if config.RootCAFile != "" {
+		pem, err := os.ReadFile(config.RootCAFile)
+		if err != nil {
+			log.Errorf("failed to read root CA file %s: %v", config.RootCAFile, err)
+		} else if pool := x509.NewCertPool(); !pool.AppendCertsFromPEM(pem) {
+			log.Errorf("no certificates found in root CA file %s", config.RootCAFile)
+		} else {
+			client.SetRootCertificateFromString(string(pem))
+		}
+	}
+
+	if config.ClientCertFile != "" || config.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			log.Errorf("failed to load client certificate: %v", err)
+		} else {
+			client.SetCertificates(cert)
+		}
+	}
+}
+
+// retryCondition adapts a status/body predicate to a resty retry condition.
+// Adding any condition disables resty's default retry on transport errors,
+// so those are retried here explicitly.
+func retryCondition(condition func(statusCode int, body []byte) bool) resty.RetryConditionFunc {
+	return func(resp *resty.Response, err error) bool {
+		if err != nil || resp == nil {
+			return true
+		}
+		return condition(resp.StatusCode(), resp.Body())
+	}
+}
+
+// retryAfter parses the Retry-After header as seconds or an HTTP date.
+// Returning zero falls back to the regular backoff. It also ends the retries
+// of a request that has used up its per-request RetryCount.
+func retryAfter(_ *resty.Client, resp *resty.Response) (time.Duration, error) {
+	if retryLimitReached(resp.Request) {
+		return 0, errRetryLimitReached
+	}
+
+	header := resp.Header().Get("Retry-After")
+	if header == "" {
+		return 0, nil
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	if at, err := http.ParseTime(header); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// RetryOnStatus returns a retry condition matching any of the given status codes
+func RetryOnStatus(codes ...int) func(statusCode int, body []byte) bool {
+	return func(statusCode int, _ []byte) bool {
+		for _, code := range codes {
+			if statusCode == code {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// hasHeader reports whether headers contains the given name, case-insensitively
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get performs a GET request
+func (c *HTTPClient) Get(path string, queryParams map[string]string, result interface{}) error {
+	return c.GetCtx(context.Background(), path, queryParams, result)
+}
+
+// GetCtx performs a GET request bound to the given context
+func (c *HTTPClient) GetCtx(ctx context.Context, path string, queryParams map[string]string, result interface{}) error {
+	return c.GetWithOptions(ctx, path, RequestOptions{QueryParams: queryParams}, result)
+}
+
+// GetWithOptions performs a GET request with per-request options
+func (c *HTTPClient) GetWithOptions(ctx context.Context, path string, opts RequestOptions, result interface{}) error {
+	ctx, cancel := withTimeout(ctx, opts.Timeout)
+	defer cancel()
+	ctx = withRetryLimit(ctx, opts.RetryCount)
+
+	_, err := c.executeGet(ctx, c.newRequest(opts), path, "GET", result)
+	return err
 }
 
 // Post performs a POST request
 func (c *HTTPClient) Post(path string, body interface{}, result interface{}) error {
-	resp, err := c.client.R().
+	return c.PostCtx(context.Background(), path, body, result)
+}
+
+// PostCtx performs a POST request bound to the given context
+func (c *HTTPClient) PostCtx(ctx context.Context, path string, body interface{}, result interface{}) error {
+	return c.PostWithOptions(ctx, path, body, RequestOptions{}, result)
+}
+
+// PostWithOptions performs a POST request with per-request options
+func (c *HTTPClient) PostWithOptions(ctx context.Context, path string, body interface{}, opts RequestOptions, result interface{}) error {
+	ctx, cancel := withTimeout(ctx, opts.Timeout)
+	defer cancel()
+	ctx = withRetryLimit(ctx, opts.RetryCount)
+
+	req := c.newRequest(opts).
 		SetBody(body).
-		SetResult(result).
-		Post(path)
+		SetResult(result)
 
-	if err != nil {
-		log.Errorf("HTTP POST request failed: %v", err)
-		return fmt.Errorf("HTTP POST request failed: %w", err)
-	}
+	_, err := c.execute(ctx, req, resty.MethodPost, path, "POST")
+	return err
+}
 
-	if resp.IsError() {
-		log.Errorf("HTTP POST request %s returned error status: %d, body: %s", path, resp.StatusCode(), resp.Body())
-		return fmt.Errorf("HTTP POST request %s returned error status: %d, body: %s", path, resp.StatusCode(), resp.Body())
+// PostExpect performs a POST request, decoding a 2xx response into
+// successResult and an error response into errorResult
+func (c *HTTPClient) PostExpect(path string, body interface{}, successResult interface{}, errorResult interface{}) error {
+	return c.PostExpectCtx(context.Background(), path, body, successResult, errorResult)
+}
+
+// PostExpectCtx performs a POST request bound to the given context, decoding
+// a 2xx response into successResult and an error response into errorResult,
+// which must be a pointer. The upstream's error envelope is then read from
+// errorResult when errors.Is(err, ErrUpstreamError); it stays unset when the
+// body isn't JSON or XML. Transport errors and circuit breaker rejections
+// don't match ErrUpstreamError.
+func (c *HTTPClient) PostExpectCtx(ctx context.Context, path string, body interface{}, successResult interface{}, errorResult interface{}) error {
+	req := c.newRequest(RequestOptions{}).
+		SetBody(body).
+		SetResult(successResult)
+	if errorResult != nil {
+		req.SetError(errorResult)
 	}
 
-	return nil
+	_, err := c.execute(ctx, req, resty.MethodPost, path, "POST")
+	return err
 }
 
 // Put performs a PUT request
 func (c *HTTPClient) Put(path string, body interface{}, result interface{}) error {
-	resp, err := c.client.R().
+	return c.PutCtx(context.Background(), path, body, result)
+}
+
+// PutCtx performs a PUT request bound to the given context
+func (c *HTTPClient) PutCtx(ctx context.Context, path string, body interface{}, result interface{}) error {
+	return c.PutWithOptions(ctx, path, body, RequestOptions{}, result)
+}
+
+// PutWithOptions performs a PUT request with per-request options
+func (c *HTTPClient) PutWithOptions(ctx context.Context, path string, body interface{}, opts RequestOptions, result interface{}) error {
+	ctx, cancel := withTimeout(ctx, opts.Timeout)
+	defer cancel()
+	ctx = withRetryLimit(ctx, opts.RetryCount)
+
+	req := c.newRequest(opts).
 		SetBody(body).
-		SetResult(result).
-		Put(path)
+		SetResult(result)
 
-	if err != nil {
-		log.Errorf("HTTP PUT request failed: %v", err)
-		return fmt.Errorf("HTTP PUT request failed: %w", err)
-	}
+	_, err := c.execute(ctx, req, resty.MethodPut, path, "PUT")
+	return err
+}
 
-	if resp.IsError() {
-		log.Errorf("HTTP PUT request returned error status: %d, body: %s", resp.StatusCode(), resp.Body())
-		return fmt.Errorf("HTTP PUT request returned error status: %d, body: %s", resp.StatusCode(), resp.Body())
-	}
+// Patch performs a PATCH request
+func (c *HTTPClient) Patch(path string, body interface{}, result interface{}) error {
+	return c.PatchCtx(context.Background(), path, body, result)
+}
 
-	return nil
+// PatchCtx performs a PATCH request bound to the given context
+func (c *HTTPClient) PatchCtx(ctx context.Context, path string, body interface{}, result interface{}) error {
+	return c.PatchWithOptions(ctx, path, body, RequestOptions{}, result)
+}
+
+// PatchWithOptions performs a PATCH request with per-request options
+func (c *HTTPClient) PatchWithOptions(ctx context.Context, path string, body interface{}, opts RequestOptions, result interface{}) error {
+	ctx, cancel := withTimeout(ctx, opts.Timeout)
+	defer cancel()
+	ctx = withRetryLimit(ctx, opts.RetryCount)
+
+	req := c.newRequest(opts).
+		SetBody(body).
+		SetResult(result)
+
+	_, err := c.execute(ctx, req, resty.MethodPatch, path, "PATCH")
+	return err
 }
 
 // Delete performs a DELETE request
 func (c *HTTPClient) Delete(path string, queryParams map[string]string, result interface{}) error {
-	resp, err := c.client.R().
-		SetQueryParams(queryParams).
-		SetResult(result).
-		Delete(path)
+	return c.DeleteCtx(context.Background(), path, queryParams, result)
+}
 
+// DeleteCtx performs a DELETE request bound to the given context
+func (c *HTTPClient) DeleteCtx(ctx context.Context, path string, queryParams map[string]string, result interface{}) error {
+	return c.DeleteWithOptions(ctx, path, RequestOptions{QueryParams: queryParams}, result)
+}
+
+// DeleteWithOptions performs a DELETE request with per-request options
+func (c *HTTPClient) DeleteWithOptions(ctx context.Context, path string, opts RequestOptions, result interface{}) error {
+	ctx, cancel := withTimeout(ctx, opts.Timeout)
+	defer cancel()
+	ctx = withRetryLimit(ctx, opts.RetryCount)
+
+	req := c.newRequest(opts).
+		SetResult(result)
+
+	_, err := c.execute(ctx, req, resty.MethodDelete, path, "DELETE")
+	return err
+}
+
+// Head performs a HEAD request and returns the response headers, e.g. to
+// check that a resource exists or read its Content-Length without the body
+func (c *HTTPClient) Head(path string, queryParams map[string]string) (http.Header, error) {
+	return c.HeadCtx(context.Background(), path, queryParams)
+}
+
+// HeadCtx performs a HEAD request bound to the given context
+func (c *HTTPClient) HeadCtx(ctx context.Context, path string, queryParams map[string]string) (http.Header, error) {
+	req := c.newRequest(RequestOptions{QueryParams: queryParams})
+
+	resp, err := c.execute(ctx, req, resty.MethodHead, path, "HEAD")
 	if err != nil {
-		log.Errorf("HTTP DELETE request failed: %v", err)
-		return fmt.Errorf("HTTP DELETE request failed: %w", err)
+		return nil, err
 	}
+	return resp.Header(), nil
+}
 
-	if resp.IsError() {
-		log.Errorf("HTTP DELETE request returned error status: %d, body: %s", resp.StatusCode(), resp.Body())
-		return fmt.Errorf("HTTP DELETE request returned error status: %d, body: %s", resp.StatusCode(), resp.Body())
-	}
+// Options performs an OPTIONS request and returns the response headers, e.g.
+// Allow or the CORS Access-Control-* headers
+func (c *HTTPClient) Options(path string) (http.Header, error) {
+	return c.OptionsCtx(context.Background(), path)
+}
 
-	return nil
+// OptionsCtx performs an OPTIONS request bound to the given context
+func (c *HTTPClient) OptionsCtx(ctx context.Context, path string) (http.Header, error) {
+	resp, err := c.execute(ctx, c.client.R(), resty.MethodOptions, path, "OPTIONS")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Header(), nil
 }
 
 // PostForm performs a POST request with form data
 func (c *HTTPClient) PostForm(path string, formData map[string]string, result interface{}) error {
-	resp, err := c.client.R().
-		SetFormData(formData).
-		SetResult(result).
-		Post(path)
+	return c.PostFormCtx(context.Background(), path, formData, result)
+}
 
-	if err != nil {
-		log.Errorf("HTTP POST form request failed: %v", err)
-		return fmt.Errorf("HTTP POST form request failed: %w", err)
+// PostFormCtx performs a POST request with form data bound to the given context.
+// It is not retried, see PostFormWithOptions.
+func (c *HTTPClient) PostFormCtx(ctx context.Context, path string, formData map[string]string, result interface{}) error {
+	return c.PostFormWithOptions(ctx, path, formData, RequestOptions{}, result)
+}
+
+// PostFormWithOptions performs a POST request with form data and per-request
+// options. Form submissions are usually not idempotent, so they are only
+// retried when opts.RetryCount is set.
+func (c *HTTPClient) PostFormWithOptions(ctx context.Context, path string, formData map[string]string, opts RequestOptions, result interface{}) error {
+	if opts.RetryCount == nil {
+		opts.RetryCount = RetryCount(0)
 	}
 
-	if resp.IsError() {
-		log.Errorf("HTTP POST form request returned error status: %d, body: %s", resp.StatusCode(), resp.Body())
-		return fmt.Errorf("HTTP POST form request returned error status: %d, body: %s", resp.StatusCode(), resp.Body())
+	ctx, cancel := withTimeout(ctx, opts.Timeout)
+	defer cancel()
+	ctx = withRetryLimit(ctx, opts.RetryCount)
+
+	req := c.newRequest(opts).
+		SetFormData(formData).
+		SetResult(result)
+
+	_, err := c.execute(ctx, req, resty.MethodPost, path, "POST form")
+	return err
+}
+
+// PatchForm performs a PATCH request with form data
+func (c *HTTPClient) PatchForm(path string, formData map[string]string, result interface{}) error {
+	return c.PatchFormCtx(context.Background(), path, formData, result)
+}
+
+// PatchFormCtx performs a PATCH request with form data bound to the given context.
+// It is not retried, see PatchFormWithOptions.
+func (c *HTTPClient) PatchFormCtx(ctx context.Context, path string, formData map[string]string, result interface{}) error {
+	return c.PatchFormWithOptions(ctx, path, formData, RequestOptions{}, result)
+}
+
+// PatchFormWithOptions performs a PATCH request with form data and
+// per-request options. Like PostFormWithOptions, it is only retried when
+// opts.RetryCount is set.
+func (c *HTTPClient) PatchFormWithOptions(ctx context.Context, path string, formData map[string]string, opts RequestOptions, result interface{}) error {
+	if opts.RetryCount == nil {
+		opts.RetryCount = RetryCount(0)
 	}
 
-	return nil
+	ctx, cancel := withTimeout(ctx, opts.Timeout)
+	defer cancel()
+	ctx = withRetryLimit(ctx, opts.RetryCount)
+
+	req := c.newRequest(opts).
+		SetFormData(formData).
+		SetResult(result)
+
+	_, err := c.execute(ctx, req, resty.MethodPatch, path, "PATCH form")
+	return err
 }
 
 // GetRaw performs a GET request and returns the raw response
 func (c *HTTPClient) GetRaw(path string, queryParams map[string]string) ([]byte, error) {
-	resp, err := c.client.R().
-		SetQueryParams(queryParams).
-		Get(path)
+	return c.GetRawCtx(context.Background(), path, queryParams)
+}
 
-	if err != nil {
-		log.Errorf("HTTP GET raw request failed: %v", err)
-		return nil, fmt.Errorf("HTTP GET raw request failed: %w", err)
-	}
+// GetRawCtx performs a GET request bound to the given context and returns the raw response
+func (c *HTTPClient) GetRawCtx(ctx context.Context, path string, queryParams map[string]string) ([]byte, error) {
+	req := c.client.R().
+		SetQueryParams(queryParams)
 
-	if resp.IsError() {
-		log.Errorf("HTTP GET raw request returned error status: %d, body: %s", resp.StatusCode(), resp.Body())
-		return nil, fmt.Errorf("HTTP GET raw request returned error status: %d, body: %s", resp.StatusCode(), resp.Body())
+	resp, err := c.executeGet(ctx, req, path, "GET raw", nil)
+	if err != nil {
+		return nil, err
 	}
 
 	return resp.Body(), nil
@@ -199,23 +717,359 @@ func (c *HTTPClient) GetRaw(path string, queryParams map[string]string) ([]byte,
 
 // PostRaw performs a POST request and returns the raw response
 func (c *HTTPClient) PostRaw(path string, body interface{}) ([]byte, error) {
-	resp, err := c.client.R().
-		SetBody(body).
-		Post(path)
+	return c.PostRawCtx(context.Background(), path, body)
+}
+
+// PostRawCtx performs a POST request bound to the given context and returns the raw response
+func (c *HTTPClient) PostRawCtx(ctx context.Context, path string, body interface{}) ([]byte, error) {
+	req := c.client.R().
+		SetBody(body)
 
+	resp, err := c.execute(ctx, req, resty.MethodPost, path, "POST raw")
 	if err != nil {
-		log.Errorf("HTTP POST raw request failed: %v", err)
-		return nil, fmt.Errorf("HTTP POST raw request failed: %w", err)
+		return nil, err
 	}
 
-	if resp.IsError() {
-		log.Errorf("HTTP POST raw request returned error status: %d, body: %s", resp.StatusCode(), resp.Body())
-		return nil, fmt.Errorf("HTTP POST raw request returned error status: %d, body: %s", resp.StatusCode(), resp.Body())
+	return resp.Body(), nil
+}
+
+// PatchRaw performs a PATCH request and returns the raw response
+func (c *HTTPClient) PatchRaw(path string, body interface{}) ([]byte, error) {
+	return c.PatchRawCtx(context.Background(), path, body)
+}
+
+// PatchRawCtx performs a PATCH request bound to the given context and returns the raw response
+func (c *HTTPClient) PatchRawCtx(ctx context.Context, path string, body interface{}) ([]byte, error) {
+	req := c.client.R().
+		SetBody(body)
+
+	resp, err := c.execute(ctx, req, resty.MethodPatch, path, "PATCH raw")
+	if err != nil {
+		return nil, err
 	}
 
 	return resp.Body(), nil
 }
 
+// withTimeout derives a context bounded by timeout when it is positive
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
+
+// newRequest creates a request with the per-request options applied.
+// Client-level headers and query params still apply unless overridden here.
+func (c *HTTPClient) newRequest(opts RequestOptions) *resty.Request {
+	req := c.client.R()
+
+	if opts.Headers != nil {
+		req.SetHeaders(opts.Headers)
+	}
+	if opts.QueryParams != nil {
+		req.SetQueryParams(opts.QueryParams)
+	}
+	if opts.PathParams != nil {
+		req.SetPathParams(opts.PathParams)
+	}
+
+	return req
+}
+
+// GetStream performs a GET request and returns the unbuffered response body.
+// The caller must close the returned reader. Non-2xx responses are reported
+// as errors before any reader is handed back.
+func (c *HTTPClient) GetStream(ctx context.Context, path string, queryParams map[string]string) (io.ReadCloser, error) {
+	req := c.client.R().
+		SetQueryParams(queryParams).
+		SetDoNotParseResponse(true)
+
+	resp, err := c.execute(ctx, req, resty.MethodGet, path, "GET stream")
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.RawBody(), nil
+}
+
+// GetCompressedStream performs a GET request asking for gzip and returns the
+// response body as received, without decompressing it, together with its
+// Content-Encoding. The encoding is empty when the server sent the body
+// uncompressed. The caller must close the returned reader.
+func (c *HTTPClient) GetCompressedStream(ctx context.Context, path string, queryParams map[string]string) (io.ReadCloser, string, error) {
+	req := c.client.R().
+		SetHeader("Accept-Encoding", "gzip").
+		SetQueryParams(queryParams).
+		SetDoNotParseResponse(true)
+
+	resp, err := c.execute(ctx, req, resty.MethodGet, path, "GET compressed stream")
+	if err != nil {
+		return nil, "", err
+	}
+
+	return resp.RawBody(), resp.Header().Get("Content-Encoding"), nil
+}
+
+// execute sends the request with the given context and converts transport
+// failures, cancellations and error statuses into errors, which the Fallback
+// may replace with a substitute response unless the caller gave up
+func (c *HTTPClient) execute(ctx context.Context, req *resty.Request, method, path, label string) (*resty.Response, error) {
+	resp, err := c.executeRequest(ctx, req, method, path, label)
+	if err == nil || c.fallback == nil {
+		return resp, err
+	}
+
+	// Nobody is waiting for a substitute once the caller's context is done
+	if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+		return nil, err
+	}
+
+	body, handled := c.fallback(path, err)
+	if !handled {
+		return nil, err
+	}
+
+	if req.Result != nil && len(body) > 0 {
+		if err := json.Unmarshal(body, req.Result); err != nil {
+			return nil, fmt.Errorf("failed to decode fallback for HTTP %s request %s: %w", label, path, err)
+		}
+	}
+
+	log.Warnf("HTTP %s request %s served by fallback after error: %v", label, path, err)
+	return fallbackResponse(req, body), nil
+}
+
+// fallbackResponse wraps a fallback body as a 200 response
+func fallbackResponse(req *resty.Request, body []byte) *resty.Response {
+	resp := &resty.Response{
+		Request: req,
+		RawResponse: &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader(body)),
+		},
+	}
+	return resp.SetBody(body)
+}
+
+// executeRequest sends the request once through resty, retries included
+func (c *HTTPClient) executeRequest(ctx context.Context, req *resty.Request, method, path, label string) (*resty.Response, error) {
+	if c.breaker != nil {
+		if err := c.breaker.allow(); err != nil {
+			log.Errorf("HTTP %s request %s rejected: %v", label, path, err)
+			return nil, err
+		}
+	}
+
+	resp, err := c.send(ctx, req, method, path)
+	if err != nil {
+		// Report cancellation and deadline errors from the caller's context explicitly
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			c.breakerRelease()
+			log.Errorf("HTTP %s request %s cancelled: %v", label, path, ctxErr)
+			return nil, fmt.Errorf("HTTP %s request %s cancelled: %w", label, path, ctxErr)
+		}
+		c.breakerFailure()
+		log.Errorf("HTTP %s request failed: %v", label, err)
+		return nil, fmt.Errorf("HTTP %s request failed: %w", label, err)
+	}
+
+	// Only server errors count against the circuit; client errors mean the upstream is healthy
+	if resp.StatusCode() >= 500 {
+		c.breakerFailure()
+	} else {
+		c.breakerSuccess()
+	}
+
+	if resp.IsError() {
+		body := resp.Body()
+		if body == nil && resp.RawBody() != nil {
+			// Unparsed (streaming) responses still need their error body read and closed
+			body, _ = io.ReadAll(io.LimitReader(resp.RawBody(), maxErrorBodySize))
+			resp.RawBody().Close()
+		}
+
+		log.Errorf("HTTP %s request %s returned error status: %d, body: %s", label, path, resp.StatusCode(), body)
+		return nil, &HTTPError{
+			Method:      method,
+			Path:        path,
+			StatusCode:  resp.StatusCode(),
+			Body:        body,
+			ErrorResult: req.Error,
+		}
+	}
+
+	return resp, nil
+}
+
+// send executes the request, refreshing the bearer token and replaying the
+// request once when the server answers 401 and a token refresher is configured.
+// Requests whose body is a reader that can't be rewound, such as a streamed
+// multipart body, aren't replayed and return the 401.
+func (c *HTTPClient) send(ctx context.Context, req *resty.Request, method, path string) (*resty.Response, error) {
+	// Keep the unexpanded path for metrics, resty rewrites req.URL
+	ctx = context.WithValue(ctx, requestPathKey{}, path)
+
+	// resty consumes reader bodies, so keep the original to rewind for a replay
+	body := req.Body
+
+	resp, err := executeWithRetryLimit(req.SetContext(ctx), method, path)
+
+	if err != nil || c.refreshToken == nil || resp.StatusCode() != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	if !rewindBody(req, body) {
+		log.Warnf("HTTP request %s got 401 but its body can't be replayed with a refreshed token", path)
+		return resp, nil
+	}
+
+	_, err, _ = c.refreshGroup.Do("token", func() (interface{}, error) {
+		// Another request already refreshed the token this one was sent with
+		c.tokenMu.RLock()
+		current := c.token
+		c.tokenMu.RUnlock()
+		if current != req.Token {
+			return nil, nil
+		}
+
+		token, err := c.refreshToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		c.tokenMu.Lock()
+		c.token = token
+		c.tokenMu.Unlock()
+		return nil, nil
+	})
+	if err != nil {
+		// Surface the original 401 when the token can't be refreshed
+		log.Errorf("HTTP token refresh failed: %v", err)
+		return resp, nil
+	}
+
+	if raw := resp.RawBody(); raw != nil {
+		raw.Close()
+	}
+
+	// The replay gets its own retry budget
+	req.Attempt = 0
+	return executeWithRetryLimit(req, method, path)
+}
+
+// rewindBody restores body on req for a replay, seeking readers back to the
+// start. It reports false when body is a reader that can't seek.
+func rewindBody(req *resty.Request, body interface{}) bool {
+	reader, ok := body.(io.Reader)
+	if !ok {
+		return true
+	}
+
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return false
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return false
+	}
+	req.SetBody(body)
+	return true
+}
+
+// injectToken sets the most recently refreshed bearer token on the request
+func (c *HTTPClient) injectToken(_ *resty.Client, req *resty.Request) error {
+	c.tokenMu.RLock()
+	token := c.token
+	c.tokenMu.RUnlock()
+
+	if token != "" {
+		req.SetAuthToken(token)
+	}
+	return nil
+}
+
+func (c *HTTPClient) breakerSuccess() {
+	if c.breaker != nil {
+		c.breaker.success()
+	}
+}
+
+func (c *HTTPClient) breakerFailure() {
+	if c.breaker != nil {
+		c.breaker.failure()
+	}
+}
+
+func (c *HTTPClient) breakerRelease() {
+	if c.breaker != nil {
+		c.breaker.release()
+	}
+}
+
+// OnBeforeRequest registers a hook run before every request is sent
+func (c *HTTPClient) OnBeforeRequest(hook func(*resty.Request)) {
+	c.client.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+		hook(req)
+		return nil
+	})
+}
+
+// OnAfterResponse registers a hook run after every response is received
+func (c *HTTPClient) OnAfterResponse(hook func(*resty.Response)) {
+	c.client.OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+		hook(resp)
+		return nil
+	})
+}
+
+// OnError registers a hook run when a request fails without a response,
+// e.g. on a transport error, after all retries
+func (c *HTTPClient) OnError(hook func(*resty.Request, error)) {
+	c.client.OnError(func(req *resty.Request, err error) {
+		var respErr *resty.ResponseError
+		if errors.As(err, &respErr) && respErr.Response != nil && respErr.Response.RawResponse != nil {
+			return
+		}
+		hook(req, err)
+	})
+}
+
+// EnableCookieJar makes the client keep cookies across requests, see
+// HTTPClientConfig.EnableCookieJar. It is a no-op if a jar is already set.
+func (c *HTTPClient) EnableCookieJar() {
+	if c.client.GetClient().Jar != nil {
+		return
+	}
+
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		log.Errorf("failed to create cookie jar: %v", err)
+		return
+	}
+	c.client.SetCookieJar(jar)
+}
+
+// SetCookies adds cookies sent on every request, with or without a cookie jar
+func (c *HTTPClient) SetCookies(cookies ...*http.Cookie) {
+	c.client.SetCookies(cookies)
+}
+
+// Unwrap returns the underlying resty client for features HTTPClient doesn't
+// expose, such as SSE or a custom redirect policy. Changes made to it apply to
+// every request sent through this HTTPClient.
+func (c *HTTPClient) Unwrap() *resty.Client {
+	return c.client
+}
+
+// Close releases the client's idle keep-alive connections. Requests still in
+// flight are unaffected, and the client remains usable afterwards.
+func (c *HTTPClient) Close() error {
+	c.client.GetClient().CloseIdleConnections()
+	return nil
+}
+
 // SetHeader sets a header for the client
 func (c *HTTPClient) SetHeader(key, value string) {
 	c.client.SetHeader(key, value)
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowServer answers after delay, or when the client gives up
+func slowServer(t *testing.T, delay time.Duration) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`))
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetWithOptionsPerRequestTimeout(t *testing.T) {
+	srv := slowServer(t, 2*time.Second)
+	c := NewHTTPClient(HTTPClientConfig{BaseURL: srv.URL, Timeout: 5 * time.Second})
+
+	start := time.Now()
+	err := c.GetWithOptions(context.Background(), "/slow", RequestOptions{Timeout: 100 * time.Millisecond}, nil)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed < 100*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("request took %v, want about the 100ms per-request timeout", elapsed)
+	}
+}
+
+// countingServer answers 503 with the given Retry-After header and counts hits
+func countingServer(t *testing.T, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestRetryConditions(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryCount int
+		perRequest *int
+		hits       int32
+	}{
+		{"client retries", 3, nil, 4},
+		{"per-request limit", 3, RetryCount(1), 2},
+		{"per-request none", 3, RetryCount(0), 1},
+		{"no client retries", 0, nil, 1},
+		{"per-request can't raise", 0, RetryCount(2), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, hits := countingServer(t, "")
+			c := NewHTTPClient(HTTPClientConfig{
+				BaseURL:         srv.URL,
+				RetryCount:      tt.retryCount,
+				RetryWaitTime:   time.Millisecond,
+				RetryConditions: []func(int, []byte) bool{RetryOnStatus(http.StatusServiceUnavailable)},
+			})
+
+			_ = c.GetWithOptions(context.Background(), "/", RequestOptions{RetryCount: tt.perRequest}, nil)
+			if got := hits.Load(); got != tt.hits {
+				t.Fatalf("hits = %d, want %d", got, tt.hits)
+			}
+		})
+	}
+}
+
+func TestRetryAfterOverridesBackoff(t *testing.T) {
+	srv, hits := countingServer(t, "1")
+	c := NewHTTPClient(HTTPClientConfig{
+		BaseURL:          srv.URL,
+		RetryCount:       1,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: 5 * time.Second,
+		RetryConditions:  []func(int, []byte) bool{RetryOnStatus(http.StatusServiceUnavailable)},
+	})
+
+	start := time.Now()
+	_ = c.GetWithOptions(context.Background(), "/", RequestOptions{}, nil)
+	if elapsed := time.Since(start); hits.Load() != 2 || elapsed < 900*time.Millisecond {
+		t.Fatalf("hits = %d after %v, want 2 after the 1s Retry-After", hits.Load(), elapsed)
+	}
+}
+
+// refreshServer answers 401 unless the request carries the refreshed token,
+// then the given status; it records each request body
+func refreshServer(t *testing.T, status int) (*httptest.Server, *[]string) {
+	t.Helper()
+	var (
+		mu     sync.Mutex
+		bodies []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &bodies
+}
+
+func TestRefreshTokenReplay(t *testing.T) {
+	refresh := func(context.Context) (string, error) { return "fresh", nil }
+
+	tests := []struct {
+		name   string
+		send   func(c *HTTPClient) error
+		bodies int
+		status int
+	}{
+		{"json body", func(c *HTTPClient) error {
+			return c.PostCtx(context.Background(), "/", map[string]string{"a": "b"}, nil)
+		}, 2, 0},
+		{"seekable reader", func(c *HTTPClient) error {
+			return c.PostCtx(context.Background(), "/", bytes.NewReader([]byte(`{"a":"b"}`)), nil)
+		}, 2, 0},
+		{"streamed multipart", func(c *HTTPClient) error {
+			return c.PostMultipartCtx(context.Background(), "/", map[string]string{"a": "b"}, nil, nil)
+		}, 1, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, bodies := refreshServer(t, http.StatusOK)
+			c := NewHTTPClient(HTTPClientConfig{BaseURL: srv.URL, RefreshToken: refresh})
+			c.SetBearerToken("stale")
+
+			err := tt.send(c)
+			var httpErr *HTTPError
+			switch {
+			case tt.status == 0 && err != nil:
+				t.Fatalf("err = %v, want the replay to succeed", err)
+			case tt.status != 0 && (!errors.As(err, &httpErr) || httpErr.StatusCode != tt.status):
+				t.Fatalf("err = %v, want HTTP %d", err, tt.status)
+			}
+
+			if len(*bodies) != tt.bodies {
+				t.Fatalf("server got %d requests, want %d", len(*bodies), tt.bodies)
+			}
+			if (*bodies)[len(*bodies)-1] != (*bodies)[0] {
+				t.Fatalf("replayed body = %q, want %q", (*bodies)[len(*bodies)-1], (*bodies)[0])
+			}
+		})
+	}
+}
+
+func TestRefreshTokenReplayGetsItsOwnRetries(t *testing.T) {
+	srv, bodies := refreshServer(t, http.StatusServiceUnavailable)
+	c := NewHTTPClient(HTTPClientConfig{
+		BaseURL:         srv.URL,
+		RetryCount:      3,
+		RetryWaitTime:   time.Millisecond,
+		RetryConditions: []func(int, []byte) bool{RetryOnStatus(http.StatusServiceUnavailable)},
+		RefreshToken:    func(context.Context) (string, error) { return "fresh", nil },
+	})
+	c.SetBearerToken("stale")
+
+	_ = c.GetWithOptions(context.Background(), "/", RequestOptions{RetryCount: RetryCount(1)}, nil)
+	// The 401, then the replay and its one retry
+	if len(*bodies) != 3 {
+		t.Fatalf("server got %d requests, want 3", len(*bodies))
+	}
+}
+
+func TestFallbackSkipsCallerCancellation(t *testing.T) {
+	srv := slowServer(t, 2*time.Second)
+	var calls atomic.Int32
+	c := NewHTTPClient(HTTPClientConfig{
+		BaseURL: srv.URL,
+		Fallback: func(string, error) ([]byte, bool) {
+			calls.Add(1)
+			return []byte(`{}`), true
+		},
+	})
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if err := c.GetCtx(cancelled, "/", nil, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled: err = %v, want context.Canceled", err)
+	}
+
+	expired, cancelExpired := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelExpired()
+	if err := c.GetCtx(expired, "/", nil, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("deadline: err = %v, want context.DeadlineExceeded", err)
+	}
+
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("Fallback called %d times, want 0", n)
+	}
+
+	failing, _ := countingServer(t, "")
+	c.client.SetBaseURL(failing.URL)
+	if err := c.GetCtx(context.Background(), "/", nil, nil); err != nil || calls.Load() != 1 {
+		t.Fatalf("upstream failure: err = %v, Fallback calls = %d, want it served by Fallback", err, calls.Load())
+	}
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// DefaultLatencyBuckets are the histogram upper bounds used by InMemoryMetrics
+var DefaultLatencyBuckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// idSegment matches path segments that are numeric IDs or UUIDs
+var idSegment = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+
+// requestPathKey holds the request path as passed to the client, before
+// path params and the base URL are applied
+type requestPathKey struct{}
+
+// MetricsCollector receives one observation per HTTP attempt, retries
+// included. status is 0 when no response was received.
+type MetricsCollector interface {
+	ObserveRequest(method, path string, status int, duration time.Duration)
+}
+
+// SetMetricsCollector reports every request to collector. pathFunc maps the
+// request path to the reported one, default TemplatePath; paths using
+// RequestOptions.PathParams are reported with their {name} placeholders.
+func (c *HTTPClient) SetMetricsCollector(collector MetricsCollector, pathFunc func(path string) string) {
+	if pathFunc == nil {
+		pathFunc = TemplatePath
+	}
+
+	c.client.OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+		collector.ObserveRequest(resp.Request.Method, pathFunc(RequestPath(resp.Request)), resp.StatusCode(), resp.Time())
+		return nil
+	})
+
+	// Transport failures never reach the after-response hooks
+	c.OnError(func(req *resty.Request, _ error) {
+		collector.ObserveRequest(req.Method, pathFunc(RequestPath(req)), 0, time.Since(req.Time))
+	})
+}
+
+// requestPath returns the path the request was made with
+func RequestPath(req *resty.Request) string {
+	if path, ok := req.Context().Value(requestPathKey{}).(string); ok {
+		return path
+	}
+	return req.URL
+}
+
+// TemplatePath strips the query string and replaces numeric and UUID path
+// segments with {id}, so "/users/42?full=1" is reported as "/users/{id}"
+func TemplatePath(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if idSegment.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// EndpointStats holds the metrics recorded for one method and path
+type EndpointStats struct {
+	Count int64
+	// Errors counts transport failures and 5xx responses
+	Errors        int64
+	TotalDuration time.Duration
+	// Buckets[i] counts requests that took at most DefaultLatencyBuckets[i]
+	Buckets []int64
+}
+
+// InMemoryMetrics is a MetricsCollector that aggregates per-endpoint counts,
+// errors and latency histograms in memory. It is safe for concurrent use.
+type InMemoryMetrics struct {
+	mu        sync.Mutex
+	endpoints map[string]*EndpointStats
+}
+
+// NewInMemoryMetrics creates an empty in-memory collector
+func NewInMemoryMetrics() *InMemoryMetrics {
+	return &InMemoryMetrics{
+		endpoints: make(map[string]*EndpointStats),
+	}
+}
+
+// ObserveRequest records one request
+func (m *InMemoryMetrics) ObserveRequest(method, path string, status int, duration time.Duration) {
+	key := method + " " + path
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.endpoints[key]
+	if !ok {
+		stats = &EndpointStats{Buckets: make([]int64, len(DefaultLatencyBuckets))}
+		m.endpoints[key] = stats
+	}
+
+	stats.Count++
+	if status == 0 || status >= 500 {
+		stats.Errors++
+	}
+	stats.TotalDuration += duration
+	for i, bound := range DefaultLatencyBuckets {
+		if duration <= bound {
+			stats.Buckets[i]++
+		}
+	}
+}
+
+// Snapshot returns a copy of the stats keyed by "METHOD path"
+func (m *InMemoryMetrics) Snapshot() map[string]EndpointStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := make(map[string]EndpointStats, len(m.endpoints))
+	for key, stats := range m.endpoints {
+		copied := *stats
+		copied.Buckets = append([]int64(nil), stats.Buckets...)
+		snapshot[key] = copied
+	}
+	return snapshot
+}
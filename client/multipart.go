@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// PostMultipart performs a multipart/form-data POST request.
+// Files are keyed by form field name and streamed from their readers rather
+// than buffered in memory; readers exposing Name() (such as *os.File) use it
+// as the part's filename.
+func (c *HTTPClient) PostMultipart(path string, fields map[string]string, files map[string]io.Reader, result interface{}) error {
+	return c.PostMultipartCtx(context.Background(), path, fields, files, result)
+}
+
+// PostMultipartCtx performs a multipart/form-data POST request bound to the given context.
+// It is never retried: a streamed body cannot be replayed.
+func (c *HTTPClient) PostMultipartCtx(ctx context.Context, path string, fields map[string]string, files map[string]io.Reader, result interface{}) error {
+	ctx = withRetryLimit(ctx, RetryCount(0))
+
+	body, contentType := streamMultipart(fields, files)
+	// Unblock the writer goroutine if the body was never fully consumed
+	defer body.Close()
+
+	req := c.client.R().
+		SetHeader("Content-Type", contentType).
+		SetBody(body).
+		SetResult(result)
+
+	_, err := c.execute(ctx, req, resty.MethodPost, path, "POST multipart")
+	return err
+}
+
+// streamMultipart encodes fields and files into a pipe as the request reads it
+func streamMultipart(fields map[string]string, files map[string]io.Reader) (*io.PipeReader, string) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		err := writeMultipart(writer, fields, files)
+		if err == nil {
+			err = writer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr, writer.FormDataContentType()
+}
+
+func writeMultipart(writer *multipart.Writer, fields map[string]string, files map[string]io.Reader) error {
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return err
+		}
+	}
+
+	for name, reader := range files {
+		filename := name
+		if named, ok := reader.(interface{ Name() string }); ok {
+			filename = filepath.Base(named.Name())
+		}
+
+		part, err := writer.CreateFormFile(name, filename)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, reader); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
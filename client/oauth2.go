@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pengenjago/fibox/cache"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/gofiber/fiber/v3/log"
+	"golang.org/x/sync/singleflight"
+)
+
+// defaultExpiryLeeway is how long before expiry an OAuth2 token is refreshed
+const defaultExpiryLeeway = 30 * time.Second
+
+// OAuth2Config enables the OAuth2 client-credentials grant: a token is fetched
+// before the first request, cached until shortly before it expires, sent as
+// the bearer token and refreshed on expiry or after a 401 response
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// Cache stores the token, default an in-memory cache. A shared cache such
+	// as RedisCache lets instances reuse one token instead of each fetching
+	// their own.
+	Cache cache.Cache
+	// ExpiryLeeway refreshes tokens this long before they expire, default 30 seconds
+	ExpiryLeeway time.Duration
+}
+
+// oauth2TokenResponse is the token endpoint's success response
+type oauth2TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// oauth2Source fetches and caches client-credentials tokens
+type oauth2Source struct {
+	config OAuth2Config
+	client *resty.Client
+	cache  cache.Cache
+	key    string
+	group  singleflight.Group
+}
+
+// newOAuth2Source creates a token source sending token requests through hc,
+// so they share the proxy and TLS settings but not the HTTPClient hooks
+func newOAuth2Source(config OAuth2Config, hc *http.Client) *oauth2Source {
+	if config.ExpiryLeeway <= 0 {
+		config.ExpiryLeeway = defaultExpiryLeeway
+	}
+
+	store := config.Cache
+	if store == nil {
+		store = cache.NewLRUCache(1)
+	}
+
+	// The secret is left out of the key, which may be stored in a shared cache
+	sum := sha256.Sum256([]byte(config.TokenURL + "|" + config.ClientID + "|" + strings.Join(config.Scopes, " ")))
+
+	return &oauth2Source{
+		config: config,
+		client: resty.NewWithClient(hc),
+		cache:  store,
+		key:    "oauth2:token:" + hex.EncodeToString(sum[:16]),
+	}
+}
+
+// token returns the cached token, fetching a new one if there is none.
+// Concurrent misses share a single token request.
+func (s *oauth2Source) token(ctx context.Context) (string, error) {
+	if token, ok := s.cached(ctx); ok {
+		return token, nil
+	}
+
+	token, err, _ := s.group.Do("token", func() (interface{}, error) {
+		if token, ok := s.cached(ctx); ok {
+			return token, nil
+		}
+		return s.fetch(ctx)
+	})
+	if err != nil {
+		return "", err
+	}
+	return token.(string), nil
+}
+
+// refresh discards the cached token, e.g. after the server rejected it, and
+// fetches a new one
+func (s *oauth2Source) refresh(ctx context.Context) (string, error) {
+	if err := s.cache.Delete(ctx, s.key); err != nil {
+		log.Errorf("failed to delete cached OAuth2 token: %v", err)
+	}
+	return s.token(ctx)
+}
+
+func (s *oauth2Source) cached(ctx context.Context) (string, bool) {
+	value, ok := s.cache.Get(ctx, s.key)
+	if !ok {
+		return "", false
+	}
+	token, ok := value.(string)
+	return token, ok && token != ""
+}
+
+// fetch requests a token from the token endpoint and caches it
+func (s *oauth2Source) fetch(ctx context.Context) (string, error) {
+	form := map[string]string{"grant_type": "client_credentials"}
+	if len(s.config.Scopes) > 0 {
+		form["scope"] = strings.Join(s.config.Scopes, " ")
+	}
+
+	var body oauth2TokenResponse
+	resp, err := s.client.R().
+		SetContext(ctx).
+		SetBasicAuth(s.config.ClientID, s.config.ClientSecret).
+		SetFormData(form).
+		SetResult(&body).
+		Post(s.config.TokenURL)
+	if err != nil {
+		return "", fmt.Errorf("OAuth2 token request failed: %w", err)
+	}
+
+	if resp.IsError() {
+		return "", fmt.Errorf("OAuth2 token request failed: %w", &HTTPError{
+			Method:     resty.MethodPost,
+			Path:       s.config.TokenURL,
+			StatusCode: resp.StatusCode(),
+			Body:       resp.Body(),
+		})
+	}
+	if body.AccessToken == "" {
+		return "", errors.New("OAuth2 token response has no access_token")
+	}
+
+	// Without expires_in the token is kept until the server rejects it
+	if body.ExpiresIn > 0 {
+		ttl := time.Duration(body.ExpiresIn) * time.Second
+		if ttl > s.config.ExpiryLeeway {
+			ttl -= s.config.ExpiryLeeway
+		} else {
+			ttl /= 2
+		}
+		// Jitter could keep the token cached past its expiry
+		err = s.cache.SetWithTTL(cache.WithTTLJitter(ctx, 0), s.key, body.AccessToken, ttl)
+	} else {
+		err = s.cache.Set(ctx, s.key, body.AccessToken)
+	}
+	if err != nil {
+		// The token is still usable for this request
+		log.Errorf("failed to cache OAuth2 token: %v", err)
+	}
+
+	return body.AccessToken, nil
+}
+
+// injectOAuth2Token sets the current OAuth2 token on the request, fetching it
+// first if needed
+func (c *HTTPClient) injectOAuth2Token(_ *resty.Client, req *resty.Request) error {
+	token, err := c.oauth2.token(req.Context())
+	if err != nil {
+		return err
+	}
+
+	// Keep the token the 401 handling compares against up to date
+	c.tokenMu.Lock()
+	c.token = token
+	c.tokenMu.Unlock()
+
+	req.SetAuthToken(token)
+	return nil
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetWithQuery performs a GET request with query parameters built from a
+// struct, see QueryValues
+func (c *HTTPClient) GetWithQuery(path string, query interface{}, result interface{}) error {
+	return c.GetWithQueryCtx(context.Background(), path, query, result)
+}
+
+// GetWithQueryCtx performs a GET request with struct query parameters bound to
+// the given context
+func (c *HTTPClient) GetWithQueryCtx(ctx context.Context, path string, query interface{}, result interface{}) error {
+	values, err := QueryValues(query)
+	if err != nil {
+		return err
+	}
+
+	req := c.client.R().
+		SetQueryParamsFromValues(values)
+
+	_, err = c.executeGet(ctx, req, path, "GET", result)
+	return err
+}
+
+// QueryValues encodes a struct as query parameters. Fields are named by their
+// `url` tag, or the field name when untagged; "-" skips a field and the
+// omitempty option, wherever it appears among the tag options, skips zero
+// values. Other options are ignored. Slices and arrays become repeated
+// parameters, nil pointers are skipped, time.Time is formatted as RFC 3339 and
+// embedded structs are flattened. url.Values and map[string]string are
+// accepted as-is.
+func QueryValues(query interface{}) (url.Values, error) {
+	values := url.Values{}
+
+	switch q := query.(type) {
+	case nil:
+		return values, nil
+	case url.Values:
+		return q, nil
+	case map[string]string:
+		for key, value := range q {
+			values.Set(key, value)
+		}
+		return values, nil
+	}
+
+	v := reflect.ValueOf(query)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return values, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("query must be a struct, got %T", query)
+	}
+
+	if err := addStructValues(values, v); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// addStructValues adds the exported fields of the struct v to values
+func addStructValues(values url.Values, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		omitEmpty := slices.Contains(strings.Split(options, ","), "omitempty")
+
+		fv := v.Field(i)
+		if field.Anonymous && tag == "" && indirectType(field.Type).Kind() == reflect.Struct && indirectType(field.Type) != timeType {
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				if err := addStructValues(values, fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		if err := addValue(values, name, fv); err != nil {
+			return fmt.Errorf("query field %s: %w", field.Name, err)
+		}
+	}
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// indirectType returns the type t points to, through any number of pointers
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// addValue adds v under name, repeating the parameter for slices and arrays
+func addValue(values url.Values, name string, v reflect.Value) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < v.Len(); i++ {
+			if err := addValue(values, name, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	value, err := formatValue(v)
+	if err != nil {
+		return err
+	}
+	values.Add(name, value)
+	return nil
+}
+
+// formatValue converts a scalar value to its query string form
+func formatValue(v reflect.Value) (string, error) {
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339), nil
+	}
+	if stringer, ok := v.Interface().(fmt.Stringer); ok {
+		return stringer.String(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice:
+		// []byte
+		return string(v.Bytes()), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
@@ -0,0 +1,29 @@
+package client
+
+import "testing"
+
+func TestQueryValuesTagOptions(t *testing.T) {
+	type query struct {
+		Name   string   `url:"name,omitempty"`
+		Tags   []string `url:"tag,omitempty,brackets"`
+		Page   int      `url:"page,brackets,omitempty"`
+		Sort   string   `url:"sort,brackets"`
+		Hidden string   `url:"-"`
+	}
+
+	values, err := QueryValues(query{Hidden: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := values.Encode(); got != "sort=" {
+		t.Fatalf("empty query = %q, want only sort=", got)
+	}
+
+	values, err = QueryValues(query{Name: "a b", Tags: []string{"x", "y"}, Page: 2, Sort: "asc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := values.Encode(), "name=a+b&page=2&sort=asc&tag=x&tag=y"; got != want {
+		t.Fatalf("query = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// errRetryLimitReached stops resty's retry loop once a request has used its
+// own retry budget; it never reaches callers
+var errRetryLimitReached = errors.New("retries stopped by the per-request RetryCount")
+
+// retryLimitKey holds the per-request retry count in the request context
+type retryLimitKey struct{}
+
+// RetryCount returns n for RequestOptions.RetryCount, e.g.
+// RequestOptions{RetryCount: RetryCount(0)} to never retry a call
+func RetryCount(n int) *int {
+	return &n
+}
+
+// withRetryLimit records the per-request retry count on ctx; a nil count
+// keeps the client's own
+func withRetryLimit(ctx context.Context, count *int) context.Context {
+	if count == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, retryLimitKey{}, max(*count, 0))
+}
+
+// retryLimitReached reports whether the request has no retries left under
+// its per-request retry count
+func retryLimitReached(req *resty.Request) bool {
+	limit, ok := req.Context().Value(retryLimitKey{}).(int)
+	return ok && req.Attempt > limit
+}
+
+// executeWithRetryLimit executes the request, treating a retry loop stopped
+// by the per-request limit as finished: the last response is returned as is
+func executeWithRetryLimit(req *resty.Request, method, path string) (*resty.Response, error) {
+	resp, err := req.Execute(method, path)
+	if errors.Is(err, errRetryLimitReached) {
+		// resty only returns the stop error when the last attempt succeeded
+		// at the transport level, so resp holds the final response
+		err = nil
+	}
+	return resp, err
+}
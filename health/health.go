@@ -0,0 +1,168 @@
+// Package health provides liveness and readiness endpoints that aggregate
+// named dependency checks, each bounded by its own timeout.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pengenjago/fibox/client"
+	"github.com/pengenjago/fibox/logging"
+	"github.com/pengenjago/fibox/response"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// DefaultCheckTimeout bounds a check registered without a timeout
+const DefaultCheckTimeout = 5 * time.Second
+
+// Check statuses reported in CheckResult
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckFunc reports whether a dependency is usable, returning early if ctx is done
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of one check
+type CheckResult struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latencyMs"`
+}
+
+type check struct {
+	name    string
+	fn      CheckFunc
+	timeout time.Duration
+}
+
+// Checker holds the checks behind the readiness endpoint. It is safe for
+// concurrent use.
+type Checker struct {
+	mu     sync.RWMutex
+	checks []check
+}
+
+// NewChecker creates a checker without checks
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Register adds a named check. A zero timeout uses DefaultCheckTimeout.
+func (h *Checker) Register(name string, fn CheckFunc, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	h.mu.Lock()
+	h.checks = append(h.checks, check{name: name, fn: fn, timeout: timeout})
+	h.mu.Unlock()
+}
+
+// Run executes all checks concurrently and returns their results by name,
+// and whether every check passed. A check that outlives its timeout is
+// reported down and left running in the background.
+func (h *Checker) Run(ctx context.Context) (map[string]CheckResult, bool) {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]CheckResult, len(checks))
+	healthy := true
+
+	for _, chk := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := runCheck(ctx, chk)
+			if result.Status == StatusDown {
+				logging.WarnWithFields("Health check failed",
+					map[string]interface{}{
+						"check": chk.name,
+						"error": result.Error,
+					})
+			}
+
+			mu.Lock()
+			results[chk.name] = result
+			if result.Status == StatusDown {
+				healthy = false
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return results, healthy
+}
+
+// runCheck executes one check within its timeout
+func runCheck(ctx context.Context, chk check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, chk.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- chk.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out: %w", ctx.Err())
+	}
+
+	result := CheckResult{
+		Status:    StatusUp,
+		LatencyMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// LivenessHandler responds 200 as long as the process can serve requests;
+// it runs no checks
+func (h *Checker) LivenessHandler() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		return response.Success(c, "OK", nil)
+	}
+}
+
+// ReadinessHandler runs every check and responds 200 with the per-check
+// results, or 503 when any check fails
+func (h *Checker) ReadinessHandler() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		results, healthy := h.Run(c.Context())
+		if !healthy {
+			return response.JSON(c, fiber.StatusServiceUnavailable, false, "Service not ready", results)
+		}
+		return response.Success(c, "OK", results)
+	}
+}
+
+// RegisterRoutes mounts the liveness handler at GET /health and the
+// readiness handler at GET /ready
+func (h *Checker) RegisterRoutes(router fiber.Router) {
+	router.Get("/health", h.LivenessHandler())
+	router.Get("/ready", h.ReadinessHandler())
+}
+
+// HTTPCheck probes an upstream with a GET request to path; any transport
+// error or non-2xx response fails the check
+func HTTPCheck(httpClient *client.HTTPClient, path string) CheckFunc {
+	return func(ctx context.Context) error {
+		return httpClient.GetCtx(ctx, path, nil, nil)
+	}
+}
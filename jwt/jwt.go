@@ -1,85 +1,332 @@
 package jwt
 
 import (
+	"context"
+	"crypto"
 	"errors"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 var (
-	ErrInvalidToken = errors.New("invalid token")
-	ErrExpiredToken = errors.New("token has expired")
+	ErrInvalidToken         = errors.New("invalid token")
+	ErrExpiredToken         = errors.New("token has expired")
+	ErrTokenNotYetValid     = errors.New("token is not valid yet")
+	ErrInvalidIssuer        = errors.New("token has an invalid issuer")
+	ErrInvalidAudience      = errors.New("token has an invalid audience")
+	ErrInvalidRefreshToken  = errors.New("invalid refresh token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrMissingSigningKey    = errors.New("no signing key configured")
+)
+
+// Token types stored in the "typ" claim
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
 )
 
 // Claims represents JWT claims
 type Claims struct {
-	UserID string `json:"userId"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID    string `json:"userId"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	TokenType string `json:"typ,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// JWTConfig holds JWT service configuration
+type JWTConfig struct {
+	// Algorithm is the signing algorithm, e.g. "HS256" (default), "RS256" or "ES256"
+	Algorithm string
+	// Secret is the shared key for HMAC algorithms
+	Secret string
+	// PrivateKey signs tokens for RSA/ECDSA algorithms (*rsa.PrivateKey or
+	// *ecdsa.PrivateKey). Leave it nil for a validation-only service.
+	PrivateKey crypto.PrivateKey
+	// PublicKey verifies tokens for RSA/ECDSA algorithms, default the public
+	// half of PrivateKey
+	PublicKey crypto.PublicKey
+	// KeyID names the current key (Secret or PrivateKey/PublicKey). Issued
+	// tokens carry it in their kid header.
+	KeyID string
+	// Keys holds previous verification keys by kid, so tokens signed before
+	// a key rotation stay valid until they expire: a secret (string or
+	// []byte) for HMAC, a public key for RSA/ECDSA, using the same Algorithm.
+	// Tokens without a kid are verified with the current key.
+	Keys map[string]interface{}
+	// AccessExpiry is the lifetime of access tokens, default 24 hours
+	AccessExpiry time.Duration
+	// RefreshExpiry is the lifetime of refresh tokens, default 7x AccessExpiry
+	RefreshExpiry time.Duration
+	// RevocationStore records revoked and rotated token IDs, default in-memory
+	RevocationStore RevocationStore
+	// Leeway tolerates clock skew between issuer and verifier when checking
+	// exp, nbf and iat, e.g. 30 seconds. Default none.
+	Leeway time.Duration
+	// RequireTokenID rejects tokens without a jti claim, which can't be
+	// revoked. Tokens issued by this package always have one.
+	RequireTokenID bool
+	// ExpectedIssuer rejects tokens whose iss claim differs, with
+	// ErrInvalidIssuer. Tokens issued by this service carry it.
+	ExpectedIssuer string
+	// ExpectedAudience rejects tokens whose aud claim doesn't include it,
+	// with ErrInvalidAudience. Tokens issued by this service carry it.
+	ExpectedAudience string
+	// AcceptUntypedTokens lets ValidateToken accept tokens without a typ
+	// claim as access tokens, for a migration window after upgrading from a
+	// version that didn't set it. Such tokens may be refresh tokens, so turn
+	// it off once they have expired (RefreshExpiry after the upgrade).
+	AcceptUntypedTokens bool
+}
+
 // JWTService handles JWT operations
 type JWTService struct {
-	secret      string
-	expiryHours int
+	method        jwt.SigningMethod
+	signKey       interface{}
+	verifyKey     interface{}
+	keyID         string
+	verifyKeys    map[string]interface{}
+	accessExpiry  time.Duration
+	refreshExpiry time.Duration
+	revoked       RevocationStore
+	leeway        time.Duration
+	requireID     bool
+	issuer        string
+	audience      string
+	untyped       bool
 }
 
 // NewJWTService creates a new JWT service
 func NewJWTService(secret string, expiryHours int) *JWTService {
-	return &JWTService{
-		secret:      secret,
-		expiryHours: expiryHours,
+	return NewJWTServiceWithConfig(JWTConfig{
+		Secret:       secret,
+		AccessExpiry: time.Duration(expiryHours) * time.Hour,
+	})
+}
+
+// NewJWTServiceWithConfig creates a new JWT service with separate access and refresh expiries
+func NewJWTServiceWithConfig(config JWTConfig) *JWTService {
+	accessExpiry := config.AccessExpiry
+	if accessExpiry <= 0 {
+		accessExpiry = 24 * time.Hour
+	}
+
+	refreshExpiry := config.RefreshExpiry
+	if refreshExpiry <= 0 {
+		refreshExpiry = accessExpiry * 7
+	}
+
+	revoked := config.RevocationStore
+	if revoked == nil {
+		revoked = NewMemoryRevocationStore()
+	}
+
+	s := &JWTService{
+		accessExpiry:  accessExpiry,
+		refreshExpiry: refreshExpiry,
+		revoked:       revoked,
+		leeway:        config.Leeway,
+		requireID:     config.RequireTokenID,
+		issuer:        config.ExpectedIssuer,
+		audience:      config.ExpectedAudience,
+		untyped:       config.AcceptUntypedTokens,
+	}
+	s.setKeys(config)
+	return s
+}
+
+// setKeys picks the signing method and keys for config.Algorithm, leaving
+// method nil for unsupported algorithms
+func (s *JWTService) setKeys(config JWTConfig) {
+	algorithm := config.Algorithm
+	if algorithm == "" {
+		algorithm = jwt.SigningMethodHS256.Alg()
+	}
+
+	switch method := jwt.GetSigningMethod(algorithm).(type) {
+	case *jwt.SigningMethodHMAC:
+		s.method = method
+		s.signKey = []byte(config.Secret)
+		s.verifyKey = []byte(config.Secret)
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		s.method = method
+		s.signKey = config.PrivateKey
+		s.verifyKey = config.PublicKey
+		if s.verifyKey == nil {
+			if signer, ok := config.PrivateKey.(crypto.Signer); ok {
+				s.verifyKey = signer.Public()
+			}
+		}
+	}
+
+	s.keyID = config.KeyID
+	s.verifyKeys = make(map[string]interface{}, len(config.Keys)+1)
+	for kid, key := range config.Keys {
+		if secret, ok := key.(string); ok {
+			key = []byte(secret)
+		}
+		s.verifyKeys[kid] = key
+	}
+	if s.keyID != "" && s.verifyKey != nil {
+		s.verifyKeys[s.keyID] = s.verifyKey
 	}
 }
 
 // GenerateToken generates a new JWT token
 func (s *JWTService) GenerateToken(userID string, email, role string) (string, error) {
-	claims := Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(s.expiryHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+	return s.sign(Claims{UserID: userID, Email: email, Role: role}, TokenTypeAccess, s.accessExpiry)
+}
+
+// GenerateRefreshToken generates a refresh token with longer expiry.
+// Refresh tokens are rejected by ValidateToken; exchange them with RefreshToken.
+func (s *JWTService) GenerateRefreshToken(userID string, email, role string) (string, error) {
+	return s.sign(Claims{UserID: userID, Email: email, Role: role}, TokenTypeRefresh, s.refreshExpiry)
+}
+
+// GenerateTokenPair generates an access token and a refresh token for the
+// UserID, Email and Role in claims
+func (s *JWTService) GenerateTokenPair(claims Claims) (access, refresh string, err error) {
+	access, err = s.GenerateToken(claims.UserID, claims.Email, claims.Role)
+	if err != nil {
+		return "", "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secret))
+	refresh, err = s.GenerateRefreshToken(claims.UserID, claims.Email, claims.Role)
+	if err != nil {
+		return "", "", err
+	}
+
+	return access, refresh, nil
 }
 
-// GenerateRefreshToken generates a refresh token with longer expiry
-func (s *JWTService) GenerateRefreshToken(userID string, email, role string) (string, error) {
-	claims := Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(s.expiryHours*7) * time.Hour)), // 7x longer
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+// RefreshToken exchanges a refresh token for a new token pair. The old refresh
+// token is invalidated, so reusing it returns ErrInvalidRefreshToken, as does
+// passing an access token.
+func (s *JWTService) RefreshToken(refresh string) (newAccess, newRefresh string, err error) {
+	claims, err := s.parse(refresh)
+	if err != nil {
+		if errors.Is(err, ErrExpiredToken) {
+			return "", "", err
+		}
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	if claims.TokenType != TokenTypeRefresh || claims.ID == "" || claims.ExpiresAt == nil {
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	if err := s.rotate(claims); err != nil {
+		return "", "", err
+	}
+
+	return s.GenerateTokenPair(*claims)
+}
+
+// Revoke rejects the token with the given ID (jti claim) from now on. The ID is
+// kept for the longest token lifetime, since its actual expiry isn't known here.
+func (s *JWTService) Revoke(tokenID string) error {
+	return s.revoked.Revoke(context.Background(), tokenID, time.Now().Add(s.refreshExpiry))
+}
+
+// RevokeToken rejects the given access or refresh token until it expires.
+// Tokens that are already expired need no revocation.
+func (s *JWTService) RevokeToken(tokenString string) error {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		if errors.Is(err, ErrExpiredToken) {
+			return nil
+		}
+		return err
+	}
+
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return ErrInvalidToken
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.secret))
+	return s.revoked.Revoke(context.Background(), claims.ID, claims.ExpiresAt.Time)
 }
 
-// ValidateToken validates a JWT token and returns claims
+// ValidateToken validates a JWT token and returns claims.
+// With ExpectedIssuer or ExpectedAudience configured, mismatching tokens are
+// rejected with ErrInvalidIssuer or ErrInvalidAudience.
+// Tokens used before their nbf, or issued (iat) in the future, are rejected
+// with ErrTokenNotYetValid, allowing for the configured Leeway. Refresh
+// tokens, tokens without a typ claim unless AcceptUntypedTokens is set, and
+// tokens without a jti when RequireTokenID is set, are rejected with
+// ErrInvalidToken.
 func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType != TokenTypeAccess && (claims.TokenType != "" || !s.untyped) {
+		return nil, ErrInvalidToken
+	}
+
+	if s.requireID && claims.ID == "" {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.ID != "" {
+		// Fail closed: a store we can't reach may be hiding a revocation
+		revoked, err := s.revoked.IsRevoked(context.Background(), claims.ID)
+		if err != nil || revoked {
 			return nil, ErrInvalidToken
 		}
-		return []byte(s.secret), nil
-	})
+	}
+
+	return claims, nil
+}
+
+// sign issues a token of the given type with a fresh ID (jti) and expiry
+func (s *JWTService) sign(claims Claims, tokenType string, expiry time.Duration) (string, error) {
+	if s.method == nil {
+		return "", ErrUnsupportedAlgorithm
+	}
+	if s.signKey == nil {
+		return "", ErrMissingSigningKey
+	}
+
+	now := time.Now()
+	claims.TokenType = tokenType
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(expiry)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Issuer:    s.issuer,
+	}
+	if s.audience != "" {
+		claims.Audience = jwt.ClaimStrings{s.audience}
+	}
+
+	token := jwt.NewWithClaims(s.method, claims)
+	if s.keyID != "" {
+		token.Header["kid"] = s.keyID
+	}
+	return token.SignedString(s.signKey)
+}
+
+// parse verifies the signature and registered claims of a token of any type.
+// Only the configured algorithm is accepted, so a token can't pick its own
+// verification method (e.g. HS256 signed with an RSA public key).
+func (s *JWTService) parse(tokenString string) (*Claims, error) {
+	if s.method == nil || (s.verifyKey == nil && len(s.verifyKeys) == 0) {
+		return nil, ErrInvalidToken
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.verificationKey, jwt.WithValidMethods([]string{s.method.Alg()}), jwt.WithIssuedAt(), jwt.WithLeeway(s.leeway))
 
 	if err != nil {
-		if errors.Is(err, jwt.ErrTokenExpired) {
+		switch {
+		case errors.Is(err, jwt.ErrTokenExpired):
 			return nil, ErrExpiredToken
+		case errors.Is(err, jwt.ErrTokenNotValidYet), errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+			return nil, ErrTokenNotYetValid
 		}
 		return nil, ErrInvalidToken
 	}
@@ -89,5 +336,44 @@ func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, ErrInvalidToken
 	}
 
+	// Checked here rather than by the parser, which reports a missing claim
+	// as a generic error
+	if s.issuer != "" && claims.Issuer != s.issuer {
+		return nil, ErrInvalidIssuer
+	}
+	if s.audience != "" && !slices.Contains(claims.Audience, s.audience) {
+		return nil, ErrInvalidAudience
+	}
+
 	return claims, nil
 }
+
+// verificationKey returns the key named by the token's kid header, or the
+// current key for tokens without one. An unknown kid is rejected rather than
+// tried against the current key.
+func (s *JWTService) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if s.verifyKey == nil {
+			return nil, ErrInvalidToken
+		}
+		return s.verifyKey, nil
+	}
+
+	key, ok := s.verifyKeys[kid]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return key, nil
+}
+
+// rotate revokes a refresh token, returning ErrInvalidRefreshToken if it
+// had already been revoked or rotated. The store checks and revokes in one
+// step, so concurrent refreshes on any instance sharing it can't both win.
+func (s *JWTService) rotate(claims *Claims) error {
+	rotated, err := s.revoked.RevokeIfAbsent(context.Background(), claims.ID, claims.ExpiresAt.Time)
+	if err != nil || !rotated {
+		return ErrInvalidRefreshToken
+	}
+	return nil
+}
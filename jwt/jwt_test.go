@@ -0,0 +1,121 @@
+package jwt
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/pengenjago/fibox/cache"
+)
+
+// TestRefreshTokenRotatesOnce replays one refresh token concurrently through
+// two services sharing a revocation store, like two instances sharing Redis
+func TestRefreshTokenRotatesOnce(t *testing.T) {
+	stores := map[string]func() RevocationStore{
+		"memory": func() RevocationStore { return NewMemoryRevocationStore() },
+		"cache":  func() RevocationStore { return NewCacheRevocationStore(cache.NewLRUCache(100)) },
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore()
+			services := []*JWTService{
+				NewJWTServiceWithConfig(JWTConfig{Secret: "secret", RevocationStore: store}),
+				NewJWTServiceWithConfig(JWTConfig{Secret: "secret", RevocationStore: store}),
+			}
+
+			refresh, err := services[0].GenerateRefreshToken("user1", "user1@example.com", "user")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var rotated atomic.Int32
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func(svc *JWTService) {
+					defer wg.Done()
+					_, _, err := svc.RefreshToken(refresh)
+					switch {
+					case err == nil:
+						rotated.Add(1)
+					case !errors.Is(err, ErrInvalidRefreshToken):
+						t.Errorf("RefreshToken: %v", err)
+					}
+				}(services[i%2])
+			}
+			wg.Wait()
+
+			if got := rotated.Load(); got != 1 {
+				t.Fatalf("refresh token rotated %d times, want 1", got)
+			}
+		})
+	}
+}
+
+// TestCacheRevocationStoreRevokeIfAbsent checks that an ID stored by Revoke
+// counts as revoked for RevokeIfAbsent
+func TestCacheRevocationStoreRevokeIfAbsent(t *testing.T) {
+	ctx := t.Context()
+	store := NewCacheRevocationStore(cache.NewLRUCache(100))
+	expiresAt := time.Now().Add(time.Hour)
+
+	if err := store.Revoke(ctx, "revoked", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		id   string
+		want bool
+	}{
+		{"revoked", false},
+		{"fresh", true},
+		{"fresh", false},
+	} {
+		got, err := store.RevokeIfAbsent(ctx, tt.id, expiresAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Fatalf("RevokeIfAbsent(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+// TestValidateTokenRejectsUntypedTokens signs a token the way versions
+// before the typ claim did: it may be a refresh token
+func TestValidateTokenRejectsUntypedTokens(t *testing.T) {
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
+		UserID: "user1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewJWTServiceWithConfig(JWTConfig{Secret: "secret"})
+	if _, err := svc.ValidateToken(legacy); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("ValidateToken(untyped) = %v, want ErrInvalidToken", err)
+	}
+
+	migrating := NewJWTServiceWithConfig(JWTConfig{Secret: "secret", AcceptUntypedTokens: true})
+	if _, err := migrating.ValidateToken(legacy); err != nil {
+		t.Fatalf("ValidateToken(untyped) with AcceptUntypedTokens: %v", err)
+	}
+
+	refresh, err := migrating.GenerateRefreshToken("user1", "user1@example.com", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrating.ValidateToken(refresh); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("ValidateToken(refresh) with AcceptUntypedTokens = %v, want ErrInvalidToken", err)
+	}
+}
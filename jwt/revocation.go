@@ -0,0 +1,152 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pengenjago/fibox/cache"
+)
+
+// revokedKeyPrefix namespaces revoked token IDs in a shared cache
+const revokedKeyPrefix = "jwt:revoked:"
+
+// purgeInterval bounds how often MemoryRevocationStore sweeps expired entries
+const purgeInterval = time.Minute
+
+// RevocationStore records revoked token IDs until the tokens would have expired.
+// IsRevoked must return an error, not false, when the store can't be reached:
+// JWTService then rejects the token. RevokeIfAbsent must check and revoke
+// atomically across every instance sharing the store, reporting true only to
+// the caller that revoked the ID; refresh token rotation relies on it.
+type RevocationStore interface {
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	RevokeIfAbsent(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error)
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+// MemoryRevocationStore keeps revoked token IDs in memory for this process only
+type MemoryRevocationStore struct {
+	mu        sync.Mutex
+	revoked   map[string]time.Time
+	lastPurge time.Time
+}
+
+// NewMemoryRevocationStore creates an in-memory revocation store
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		revoked:   make(map[string]time.Time),
+		lastPurge: time.Now(),
+	}
+}
+
+// Revoke marks tokenID as revoked until expiresAt. Expired entries are purged
+// periodically as new IDs are revoked.
+func (s *MemoryRevocationStore) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.purge(time.Now())
+	s.revoked[tokenID] = expiresAt
+	return nil
+}
+
+// RevokeIfAbsent revokes tokenID unless it is already revoked, reporting
+// whether this call revoked it
+func (s *MemoryRevocationStore) RevokeIfAbsent(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.purge(now)
+	if exp, ok := s.revoked[tokenID]; ok && !now.After(exp) {
+		return false, nil
+	}
+	s.revoked[tokenID] = expiresAt
+	return true, nil
+}
+
+// purge drops expired entries at most once per purgeInterval; s.mu must be held
+func (s *MemoryRevocationStore) purge(now time.Time) {
+	if now.Sub(s.lastPurge) < purgeInterval {
+		return
+	}
+	for id, exp := range s.revoked {
+		if now.After(exp) {
+			delete(s.revoked, id)
+		}
+	}
+	s.lastPurge = now
+}
+
+// IsRevoked reports whether tokenID was revoked and hasn't expired yet
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	exp, ok := s.revoked[tokenID]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(exp) {
+		delete(s.revoked, tokenID)
+		return false, nil
+	}
+	return true, nil
+}
+
+// CacheRevocationStore keeps revoked token IDs in a cache.Cache, e.g. a
+// RedisCache so revocations are shared between instances
+type CacheRevocationStore struct {
+	cache cache.Cache
+}
+
+// NewCacheRevocationStore creates a revocation store backed by c
+func NewCacheRevocationStore(c cache.Cache) *CacheRevocationStore {
+	return &CacheRevocationStore{cache: c}
+}
+
+// Revoke stores tokenID with a TTL that ends when the token expires
+func (s *CacheRevocationStore) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	// Jitter could end the revocation before the token expires
+	return s.cache.SetWithTTL(cache.WithTTLJitter(ctx, 0), revokedKeyPrefix+tokenID, true, ttl)
+}
+
+// RevokeIfAbsent revokes tokenID unless it is already revoked, reporting
+// whether this call revoked it. It relies on IncrementWithTTL being atomic
+// (a script on Redis), so only the first caller sees a count of 1; an ID
+// stored by Revoke isn't an integer and counts as already revoked.
+func (s *CacheRevocationStore) RevokeIfAbsent(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return true, nil
+	}
+
+	count, err := s.cache.IncrementWithTTL(ctx, revokedKeyPrefix+tokenID, 1, ttl)
+	if err != nil {
+		if errors.Is(err, cache.ErrNotInteger) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return count == 1, nil
+}
+
+// IsRevoked reports whether tokenID is present in the cache. A cache that
+// can't be reached returns an error rather than a miss, which Get would
+// report, so an outage doesn't quietly accept revoked tokens.
+func (s *CacheRevocationStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	key := revokedKeyPrefix + tokenID
+	values, err := s.cache.GetMany(ctx, []string{key})
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	_, ok := values[key]
+	return ok, nil
+}
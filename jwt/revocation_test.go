@@ -0,0 +1,59 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pengenjago/fibox/cache"
+)
+
+// failingCache is a working LRU cache whose lookups fail once down is set,
+// like a Redis cache during an outage
+type failingCache struct {
+	cache.Cache
+	down bool
+}
+
+func (c *failingCache) GetMany(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	if c.down {
+		return nil, errors.New("connection refused")
+	}
+	return c.Cache.GetMany(ctx, keys)
+}
+
+func TestCacheRevocationStoreFailsClosed(t *testing.T) {
+	store := &failingCache{Cache: cache.NewLRUCache(100)}
+	svc := NewJWTServiceWithConfig(JWTConfig{
+		Secret:          "secret",
+		RevocationStore: NewCacheRevocationStore(store),
+	})
+
+	token, err := svc.GenerateToken("user1", "user1@example.com", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ValidateToken(token); err != nil {
+		t.Fatalf("ValidateToken before revocation: %v", err)
+	}
+
+	if err := svc.RevokeToken(token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ValidateToken(token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("ValidateToken after revocation = %v, want ErrInvalidToken", err)
+	}
+
+	store.down = true
+	if _, err := NewCacheRevocationStore(store).IsRevoked(context.Background(), "any"); err == nil {
+		t.Fatal("IsRevoked with the cache down returned no error")
+	}
+
+	fresh, err := svc.GenerateToken("user2", "user2@example.com", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ValidateToken(fresh); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("ValidateToken with the store down = %v, want ErrInvalidToken", err)
+	}
+}
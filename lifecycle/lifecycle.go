@@ -0,0 +1,94 @@
+// Package lifecycle coordinates graceful shutdown: components register
+// cleanup functions once, and Shutdown runs them all within a deadline.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/pengenjago/fibox/logging"
+)
+
+// CloseFunc releases a component's resources, returning early if ctx is done
+type CloseFunc func(ctx context.Context) error
+
+type hook struct {
+	name string
+	fn   CloseFunc
+}
+
+// Registry collects cleanup functions so a service can release everything
+// from one place, e.g. its SIGTERM handler. It is safe for concurrent use.
+type Registry struct {
+	mu    sync.Mutex
+	hooks []hook
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a cleanup function. Functions run in reverse registration
+// order, so components registered after their dependencies close first.
+func (r *Registry) Register(name string, fn CloseFunc) {
+	r.mu.Lock()
+	r.hooks = append(r.hooks, hook{name: name, fn: fn})
+	r.mu.Unlock()
+}
+
+// RegisterCloser adds any io.Closer, such as a Cache or an HTTPClient
+func (r *Registry) RegisterCloser(name string, closer io.Closer) {
+	r.Register(name, func(context.Context) error {
+		return closer.Close()
+	})
+}
+
+// Shutdown runs every registered function in reverse order and returns their
+// joined errors. When ctx is done, the remaining functions are skipped and
+// ctx.Err() is included; a function that ignores ctx is left running in the
+// background. The registry is emptied, so later calls are no-ops.
+func (r *Registry) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	hooks := r.hooks
+	r.hooks = nil
+	r.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown skipped %s: %w", h.name, err))
+			continue
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- h.fn(ctx)
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				logging.ErrorWithFields("Shutdown hook failed", err,
+					map[string]interface{}{
+						"name": h.name,
+					})
+				errs = append(errs, fmt.Errorf("shutdown %s: %w", h.name, err))
+				continue
+			}
+			logging.DebugWithFields("Shutdown hook completed",
+				map[string]interface{}{
+					"name": h.name,
+				})
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("shutdown %s: %w", h.name, ctx.Err()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Standard field keys shared by the middleware, so logs from every service
+// can be queried with one schema
+const (
+	FieldRequestID = "request_id"
+	FieldUserID    = "user_id"
+	FieldMethod    = "method"
+	FieldPath      = "path"
+	FieldStatus    = "status"
+	FieldLatencyMS = "latency_ms"
+	FieldIP        = "ip"
+)
+
+// RequestFields holds the standard fields describing an HTTP request.
+// Zero values are left out when the fields are logged.
+type RequestFields struct {
+	RequestID string
+	UserID    string
+	Method    string
+	Path      string
+	IP        string
+	Status    int
+	Latency   time.Duration
+}
+
+// Map returns the non-zero fields keyed by the standard field keys, ready
+// for the *WithFields functions
+func (f RequestFields) Map() map[string]interface{} {
+	fields := make(map[string]interface{}, 7)
+	for key, value := range map[string]string{
+		FieldRequestID: f.RequestID,
+		FieldUserID:    f.UserID,
+		FieldMethod:    f.Method,
+		FieldPath:      f.Path,
+		FieldIP:        f.IP,
+	} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	if f.Status != 0 {
+		fields[FieldStatus] = f.Status
+	}
+	if f.Latency != 0 {
+		fields[FieldLatencyMS] = float64(f.Latency.Microseconds()) / 1000
+	}
+	return fields
+}
+
+// Apply adds the non-zero fields to event
+func (f RequestFields) Apply(event *zerolog.Event) *zerolog.Event {
+	return event.Fields(f.Map())
+}
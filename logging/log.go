@@ -1,7 +1,12 @@
 package logging
 
 import (
+	"context"
+	"io"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -9,6 +14,177 @@ import (
 
 var Logger zerolog.Logger = log.Logger
 
+type loggerKey struct{}
+
+// ErrorHandler receives error, fatal and panic entries logged through this
+// package, e.g. to forward them to an error-tracking service
+type ErrorHandler func(msg string, err error, fields map[string]interface{})
+
+var (
+	hooksMu       sync.RWMutex
+	hooks         []zerolog.Hook
+	errorHandlers []ErrorHandler
+)
+
+// RegisterHook adds a zerolog hook to the package Logger, kept across later
+// Configure calls. Hooks run synchronously for every event.
+func RegisterHook(hook zerolog.Hook) {
+	hooksMu.Lock()
+	hooks = append(hooks, hook)
+	hooksMu.Unlock()
+
+	Logger = Logger.Hook(hook)
+}
+
+// OnError registers a handler called for every Error*, Fatal* and Panic*
+// call, with the message, error and fields as passed. Handlers run
+// synchronously on the logging goroutine, before a fatal entry exits or a
+// panic entry panics, so slow work such as network calls should be handed off
+// to a goroutine or buffered queue. Entries from ErrorCtx carry no fields.
+func OnError(handler ErrorHandler) {
+	hooksMu.Lock()
+	errorHandlers = append(errorHandlers, handler)
+	hooksMu.Unlock()
+}
+
+// notifyError calls the registered error handlers
+func notifyError(msg string, err error, fields map[string]interface{}) {
+	hooksMu.RLock()
+	handlers := errorHandlers
+	hooksMu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(msg, err, fields)
+	}
+}
+
+// Options configures the package-level Logger
+type Options struct {
+	// Writer receives log output, default os.Stderr
+	Writer io.Writer
+	// Format is "json" (default) or "console" for human-readable output
+	Format string
+	// Level is the global log level as accepted by SetLogLevel; empty leaves it unchanged
+	Level string
+	// TimeFormat is the timestamp layout, default time.RFC3339
+	TimeFormat string
+	// WithCaller adds the caller's file:line to every entry. Frames are
+	// skipped so the package functions (Info, ErrorWithFields, InfoCtx, ...)
+	// report their call site; events built directly on Logger or
+	// FromContext report one frame too high.
+	WithCaller bool
+	// Sampling limits log volume per level, keyed by "debug", "info" or
+	// "warn". Error, fatal and panic entries are never sampled.
+	Sampling map[string]SamplingRule
+}
+
+// SamplingRule keeps the first Burst entries of each Period, then one in
+// every Every entries until the period ends; Every zero drops them all
+type SamplingRule struct {
+	Burst  uint32
+	Period time.Duration
+	Every  uint32
+}
+
+// NewSampler returns a zerolog.Sampler applying rule, e.g. to sample a
+// subset of a logger's events
+func NewSampler(rule SamplingRule) zerolog.Sampler {
+	sampler := &zerolog.BurstSampler{
+		Burst:  rule.Burst,
+		Period: rule.Period,
+	}
+	if rule.Every > 0 {
+		sampler.NextSampler = &zerolog.BasicSampler{N: rule.Every}
+	}
+	return sampler
+}
+
+// levelSampler builds a per-level sampler from rules, leaving error and
+// higher levels unsampled. It returns nil when no rule applies.
+func levelSampler(rules map[string]SamplingRule) zerolog.Sampler {
+	sampler := zerolog.LevelSampler{}
+	sampled := false
+
+	for level, rule := range rules {
+		switch strings.ToLower(level) {
+		case "debug":
+			sampler.DebugSampler = NewSampler(rule)
+		case "info":
+			sampler.InfoSampler = NewSampler(rule)
+		case "warn", "warning":
+			sampler.WarnSampler = NewSampler(rule)
+		default:
+			continue
+		}
+		sampled = true
+	}
+
+	if !sampled {
+		return nil
+	}
+	return sampler
+}
+
+// Configure rebuilds the package-level Logger from opts.
+// It should be called once during startup, before logging concurrently.
+func Configure(opts Options) {
+	writer := opts.Writer
+	if writer == nil {
+		writer = os.Stderr
+	}
+
+	timeFormat := opts.TimeFormat
+	if timeFormat == "" {
+		timeFormat = time.RFC3339
+	}
+
+	if strings.ToLower(opts.Format) == "console" {
+		writer = zerolog.ConsoleWriter{
+			Out:        writer,
+			TimeFormat: timeFormat,
+		}
+	} else {
+		zerolog.TimeFieldFormat = timeFormat
+	}
+
+	logContext := zerolog.New(writer).With().Timestamp()
+	if opts.WithCaller {
+		// Skip the package function wrapping the zerolog call
+		logContext = logContext.CallerWithSkipFrameCount(zerolog.CallerSkipFrameCount + 1)
+	}
+	Logger = logContext.Logger()
+	if sampler := levelSampler(opts.Sampling); sampler != nil {
+		Logger = Logger.Sample(sampler)
+	}
+
+	hooksMu.RLock()
+	for _, hook := range hooks {
+		Logger = Logger.Hook(hook)
+	}
+	hooksMu.RUnlock()
+
+	if opts.Level != "" {
+		SetLogLevel(opts.Level)
+	}
+}
+
+// WithFields returns a context whose logger includes the given fields in
+// addition to any already attached to ctx
+func WithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	logger := FromContext(ctx).With().Fields(fields).Logger()
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger attached to ctx, or the package Logger if none
+func FromContext(ctx context.Context) zerolog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(zerolog.Logger); ok {
+			return logger
+		}
+	}
+	return Logger
+}
+
 // Info logs an info message
 func Info(msg string) {
 	Logger.Info().Msg(msg)
@@ -25,6 +201,7 @@ func InfoWithFields(msg string, fields map[string]interface{}) {
 
 // Error logs an error message
 func Error(msg string, err error) {
+	notifyError(msg, err, nil)
 	if err != nil {
 		Logger.Error().Err(err).Msg(msg)
 	} else {
@@ -34,6 +211,7 @@ func Error(msg string, err error) {
 
 // ErrorWithFields logs an error message with additional fields
 func ErrorWithFields(msg string, err error, fields map[string]interface{}) {
+	notifyError(msg, err, fields)
 	event := Logger.Error()
 	if err != nil {
 		event = event.Err(err)
@@ -72,6 +250,81 @@ func WarnWithFields(msg string, fields map[string]interface{}) {
 	event.Msg(msg)
 }
 
+// Fatal logs a fatal message and then calls os.Exit(1); deferred functions do not run
+func Fatal(msg string, err error) {
+	notifyError(msg, err, nil)
+	if err != nil {
+		Logger.Fatal().Err(err).Msg(msg)
+	} else {
+		Logger.Fatal().Msg(msg)
+	}
+}
+
+// FatalWithFields logs a fatal message with additional fields and then calls os.Exit(1)
+func FatalWithFields(msg string, err error, fields map[string]interface{}) {
+	notifyError(msg, err, fields)
+	event := Logger.Fatal()
+	if err != nil {
+		event = event.Err(err)
+	}
+	for k, v := range fields {
+		event = event.Interface(k, v)
+	}
+	event.Msg(msg)
+}
+
+// Panic logs a panic message and then panics with the message
+func Panic(msg string, err error) {
+	notifyError(msg, err, nil)
+	if err != nil {
+		Logger.Panic().Err(err).Msg(msg)
+	} else {
+		Logger.Panic().Msg(msg)
+	}
+}
+
+// PanicWithFields logs a panic message with additional fields and then panics with the message
+func PanicWithFields(msg string, err error, fields map[string]interface{}) {
+	notifyError(msg, err, fields)
+	event := Logger.Panic()
+	if err != nil {
+		event = event.Err(err)
+	}
+	for k, v := range fields {
+		event = event.Interface(k, v)
+	}
+	event.Msg(msg)
+}
+
+// InfoCtx logs an info message with the context logger
+func InfoCtx(ctx context.Context, msg string) {
+	logger := FromContext(ctx)
+	logger.Info().Msg(msg)
+}
+
+// ErrorCtx logs an error message with the context logger
+func ErrorCtx(ctx context.Context, msg string, err error) {
+	notifyError(msg, err, nil)
+	logger := FromContext(ctx)
+	event := logger.Error()
+	if err != nil {
+		event = event.Err(err)
+	}
+	event.Msg(msg)
+}
+
+// DebugCtx logs a debug message with the context logger
+func DebugCtx(ctx context.Context, msg string) {
+	logger := FromContext(ctx)
+	logger.Debug().Msg(msg)
+}
+
+// WarnCtx logs a warning message with the context logger
+func WarnCtx(ctx context.Context, msg string) {
+	logger := FromContext(ctx)
+	logger.Warn().Msg(msg)
+}
+
 // SetLogLevel sets the global log level
 func SetLogLevel(level string) {
 	zerolog.SetGlobalLevel(parseLogLevel(level))
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// callerLine returns the line it is called from
+func callerLine() int {
+	_, _, line, _ := runtime.Caller(1)
+	return line
+}
+
+func TestCallerReportsCallSite(t *testing.T) {
+	saved := Logger
+	t.Cleanup(func() { Logger = saved })
+
+	var buf bytes.Buffer
+	Configure(Options{Writer: &buf, WithCaller: true})
+
+	ctx := WithFields(context.Background(), map[string]interface{}{"requestId": "abc"})
+	tests := []struct {
+		name string
+		log  func() int
+	}{
+		{"Info", func() int { Info("info"); return callerLine() }},
+		{"ErrorWithFields", func() int { ErrorWithFields("error", errors.New("boom"), nil); return callerLine() }},
+		{"InfoCtx", func() int { InfoCtx(context.Background(), "info"); return callerLine() }},
+		{"InfoCtx with fields", func() int { InfoCtx(ctx, "info"); return callerLine() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			line := tt.log()
+
+			var entry map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("invalid log entry %q: %v", buf.String(), err)
+			}
+
+			caller, _ := entry["caller"].(string)
+			want := "log_test.go:" + strconv.Itoa(line)
+			if !strings.HasSuffix(caller, want) {
+				t.Fatalf("caller = %q, want suffix %q", caller, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/pengenjago/fibox/response"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// DefaultAPIKeyHeader is the header NewAPIKeyAuth reads by default
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// APIKeyConfig holds API key authentication configuration
+type APIKeyConfig struct {
+	// Header carries the API key, default X-API-Key
+	Header string
+	// Keys maps each valid key to the caller identity stored in Locals.
+	// The identity may be empty.
+	Keys map[string]string
+	// Validator checks keys not found in Keys, e.g. against a database, and
+	// returns the caller identity
+	Validator func(key string) (identity string, ok bool)
+}
+
+type apiKey struct {
+	hash     [sha256.Size]byte
+	identity string
+}
+
+// NewAPIKeyAuth creates middleware that requires a valid API key header,
+// responding 401 otherwise. Keys are compared in constant time, and the
+// matching caller identity is available through GetAPIKeyIdentity.
+func NewAPIKeyAuth(config APIKeyConfig) fiber.Handler {
+	header := config.Header
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+
+	keys := make([]apiKey, 0, len(config.Keys))
+	for key, identity := range config.Keys {
+		keys = append(keys, apiKey{hash: sha256.Sum256([]byte(key)), identity: identity})
+	}
+
+	return func(c fiber.Ctx) error {
+		key := c.Get(header)
+		if key == "" {
+			return response.Unauthorized(c, "API key is required")
+		}
+
+		identity, ok := matchAPIKey(keys, key)
+		if !ok && config.Validator != nil {
+			identity, ok = config.Validator(key)
+		}
+		if !ok {
+			return response.Unauthorized(c, "Invalid API key")
+		}
+
+		setLocal(c, APIKeyIdentityKey, identity)
+		return c.Next()
+	}
+}
+
+// matchAPIKey compares key against every configured key, hashing first so
+// neither the position of a match nor the key length leaks through timing
+func matchAPIKey(keys []apiKey, key string) (string, bool) {
+	hash := sha256.Sum256([]byte(key))
+
+	identity, found := "", 0
+	for _, k := range keys {
+		if subtle.ConstantTimeCompare(hash[:], k.hash[:]) == 1 {
+			identity, found = k.identity, 1
+		}
+	}
+	return identity, found == 1
+}
+
+// GetAPIKeyIdentity returns the caller identity stored by NewAPIKeyAuth, or "" if none
+func GetAPIKeyIdentity(c fiber.Ctx) string {
+	identity, _ := local(c, APIKeyIdentityKey).(string)
+	return identity
+}
@@ -2,8 +2,10 @@ package middleware
 
 import (
 	"strings"
+	"time"
 
 	"github.com/pengenjago/fibox/jwt"
+	"github.com/pengenjago/fibox/logging"
 	"github.com/pengenjago/fibox/response"
 
 	"github.com/gofiber/fiber/v3"
@@ -15,39 +17,208 @@ type AuthInfo struct {
 	Role   string `json:"role"`
 }
 
+// AuthConfig holds JWT authentication configuration. The token is read from
+// the first source that carries one, in order: the Authorization header,
+// the cookie, then the query parameter. A malformed Authorization header is
+// rejected rather than falling back to the other sources.
+type AuthConfig struct {
+	// JWT validates the token
+	JWT *jwt.JWTService
+	// CookieName reads the token from this cookie, e.g. an httpOnly cookie
+	// set at login. Cookies are sent by browsers automatically, so pair it
+	// with SameSite cookies or CSRF protection.
+	CookieName string
+	// QueryParam reads the token from this query parameter, e.g. for
+	// WebSocket or download links. Tokens in URLs can end up in proxy logs.
+	QueryParam string
+	// DisableHeader ignores the Authorization header, e.g. for cookie-only apps
+	DisableHeader bool
+}
+
+// AuthMiddleware creates middleware that requires a valid bearer token in the
+// Authorization header, responding 401 otherwise
 func AuthMiddleware(jwtSvc *jwt.JWTService) fiber.Handler {
+	return NewAuth(AuthConfig{JWT: jwtSvc})
+}
+
+// NewAuth creates middleware that requires a valid token from one of the
+// configured sources, responding 401 otherwise
+func NewAuth(config AuthConfig) fiber.Handler {
+	missing := "Authorization header is required"
+	if config.CookieName != "" || config.QueryParam != "" {
+		missing = "Authentication token is required"
+	}
+
 	return func(c fiber.Ctx) error {
-		authHeader := c.Get("Authorization")
-		if authHeader == "" {
-			return response.Unauthorized(c, "Authorization header is required")
+		tokenString, present := extractToken(c, config)
+		if !present {
+			return response.Unauthorized(c, missing)
 		}
-
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		if tokenString == "" {
 			return response.Unauthorized(c, "Invalid authorization header format")
 		}
 
-		tokenString := parts[1]
-		claims, err := jwtSvc.ValidateToken(tokenString)
+		claims, err := config.JWT.ValidateToken(tokenString)
 		if err != nil {
 			if err == jwt.ErrExpiredToken {
 				return response.Unauthorized(c, "Token has expired")
 			}
+			if err == jwt.ErrTokenNotYetValid {
+				return response.Unauthorized(c, "Token is not valid yet")
+			}
 			return response.Unauthorized(c, "Invalid token")
 		}
 
-		c.Locals("userID", claims.UserID)
-		c.Locals("email", claims.Email)
-		c.Locals("role", claims.Role)
+		setAuthLocals(c, claims)
 
 		return c.Next()
 	}
 }
 
+// OptionalAuthMiddleware validates a bearer token when one is present and
+// stores its claims like AuthMiddleware, but lets anonymous requests and
+// requests with a missing or invalid token through. Handlers can branch on
+// GetAuthInfo(c).UserID being empty.
+func OptionalAuthMiddleware(jwtSvc *jwt.JWTService) fiber.Handler {
+	return NewOptionalAuth(AuthConfig{JWT: jwtSvc})
+}
+
+// NewOptionalAuth is OptionalAuthMiddleware reading the token from the
+// configured sources, like NewAuth
+func NewOptionalAuth(config AuthConfig) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		tokenString, _ := extractToken(c, config)
+		if tokenString == "" {
+			return c.Next()
+		}
+
+		claims, err := config.JWT.ValidateToken(tokenString)
+		if err != nil {
+			return c.Next()
+		}
+
+		setAuthLocals(c, claims)
+
+		return c.Next()
+	}
+}
+
+// extractToken returns the token from the first configured source carrying
+// one. present is false when none does; the token is empty when the
+// Authorization header is present but malformed.
+func extractToken(c fiber.Ctx, config AuthConfig) (token string, present bool) {
+	if !config.DisableHeader {
+		if authHeader := c.Get("Authorization"); authHeader != "" {
+			token, _ = parseBearer(authHeader)
+			return token, true
+		}
+	}
+
+	if config.CookieName != "" {
+		if token = c.Cookies(config.CookieName); token != "" {
+			return token, true
+		}
+	}
+
+	if config.QueryParam != "" {
+		if token = c.Query(config.QueryParam); token != "" {
+			return token, true
+		}
+	}
+
+	return "", false
+}
+
+// parseBearer extracts the token from a "Bearer <token>" header value
+func parseBearer(authHeader string) (string, bool) {
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// setAuthLocals stores validated claims for GetAuthInfo and RequireRole, and
+// attaches the user ID to the context logger as user_id
+func setAuthLocals(c fiber.Ctx, claims *jwt.Claims) {
+	SetUserID(c, claims.UserID)
+	SetEmail(c, claims.Email)
+	SetRole(c, claims.Role)
+	if claims.ExpiresAt != nil {
+		SetTokenExpiry(c, claims.ExpiresAt.Time)
+	}
+
+	c.SetContext(logging.WithFields(c.Context(), map[string]interface{}{
+		logging.FieldUserID: claims.UserID,
+	}))
+}
+
+// RequireRole creates middleware that only lets through users whose role is
+// one of roles. It must run after AuthMiddleware: a request without a role in
+// Locals is treated as unauthenticated (401), a role outside the set as forbidden (403).
+func RequireRole(roles ...string) fiber.Handler {
+	allowed := make(map[string]struct{}, len(roles))
+	for _, role := range roles {
+		allowed[role] = struct{}{}
+	}
+
+	return func(c fiber.Ctx) error {
+		role, ok := Role(c)
+		if !ok {
+			return response.Unauthorized(c, "Authentication is required")
+		}
+
+		if _, ok := allowed[role]; !ok {
+			return response.Forbidden(c, "Insufficient role")
+		}
+
+		return c.Next()
+	}
+}
+
+// GetAuthInfo returns the authenticated user's info stored by AuthMiddleware.
+// It returns a zero AuthInfo when the request isn't authenticated.
 func GetAuthInfo(c fiber.Ctx) AuthInfo {
+	userID, _ := UserID(c)
+	email, _ := Email(c)
+	role, _ := Role(c)
+
 	return AuthInfo{
-		UserID: c.Locals("userID").(string),
-		Email:  c.Locals("email").(string),
-		Role:   c.Locals("role").(string),
+		UserID: userID,
+		Email:  email,
+		Role:   role,
+	}
+}
+
+// MustGetAuthInfo returns the authenticated user's info and panics when the
+// request isn't authenticated. Use it only on routes behind AuthMiddleware.
+func MustGetAuthInfo(c fiber.Ctx) AuthInfo {
+	info := GetAuthInfo(c)
+	if info.UserID == "" {
+		panic("middleware: MustGetAuthInfo called on an unauthenticated request")
+	}
+	return info
+}
+
+// TokenExpiry returns when the request's token expires. ok is false when the
+// request isn't authenticated or the token has no exp claim, i.e. never expires.
+func TokenExpiry(c fiber.Ctx) (expiry time.Time, ok bool) {
+	expiry, ok = local(c, TokenExpiryKey).(time.Time)
+	return expiry, ok
+}
+
+// TokenTimeLeft returns how long until the request's token expires, or zero
+// once it has. It also returns zero when TokenExpiry reports no expiry, so
+// check TokenExpiry to tell a non-expiring token from an expired one.
+func TokenTimeLeft(c fiber.Ctx) time.Duration {
+	expiry, ok := TokenExpiry(c)
+	if !ok {
+		return 0
+	}
+
+	left := time.Until(expiry)
+	if left < 0 {
+		return 0
 	}
+	return left
 }
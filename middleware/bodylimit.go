@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/pengenjago/fibox/response"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// NewBodyLimit creates middleware that rejects request bodies larger than
+// maxBytes with a 413 response envelope. The declared Content-Length is checked
+// first, then the actual body size, which catches chunked requests without one.
+// Sizes are of the body as sent: a compressed body is never inflated here.
+// It panics if maxBytes isn't positive.
+//
+// fiber's Config.BodyLimit (default 4MB) is enforced by the server before any
+// middleware runs and answers with a plain error, so NewBodyLimit can only
+// tighten it per route. Set the app-wide BodyLimit to the largest limit used.
+func NewBodyLimit(maxBytes int) fiber.Handler {
+	if maxBytes <= 0 {
+		panic("middleware: NewBodyLimit maxBytes must be positive")
+	}
+	message := fmt.Sprintf("Request body must not exceed %d bytes", maxBytes)
+
+	return func(c fiber.Ctx) error {
+		if c.Request().Header.ContentLength() > maxBytes || len(c.Request().Body()) > maxBytes {
+			return response.PayloadTooLarge(c, message)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func newBodyLimitApp(maxBytes int) *fiber.App {
+	app := fiber.New()
+	app.Post("/", NewBodyLimit(maxBytes), func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestBodyLimit(t *testing.T) {
+	app := newBodyLimitApp(4096)
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write(bytes.Repeat([]byte("x"), 1<<20))
+	_ = zw.Close()
+	if compressed.Len() > 4096 {
+		t.Fatalf("compressed body is %d bytes, want it within the limit", compressed.Len())
+	}
+
+	tests := []struct {
+		name     string
+		body     []byte
+		chunked  bool
+		encoding string
+		want     int
+	}{
+		{"within limit", []byte(strings.Repeat("x", 4096)), false, "", fiber.StatusOK},
+		{"oversized", []byte(strings.Repeat("x", 4097)), false, "", fiber.StatusRequestEntityTooLarge},
+		{"oversized chunked", []byte(strings.Repeat("x", 4097)), true, "", fiber.StatusRequestEntityTooLarge},
+		// Limited on the bytes sent, without inflating the body
+		{"small gzip of a large body", compressed.Bytes(), false, "gzip", fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+			if tt.encoding != "" {
+				req.Header.Set(fiber.HeaderContentEncoding, tt.encoding)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestBodyLimitRejectsNonPositiveLimit(t *testing.T) {
+	for _, maxBytes := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewBodyLimit(%d) didn't panic", maxBytes)
+				}
+			}()
+			NewBodyLimit(maxBytes)
+		}()
+	}
+}
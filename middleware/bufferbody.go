@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/pengenjago/fibox/response"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// DefaultBufferedBodyLimit is the largest body NewBufferedBody buffers when
+// no limit is given, matching fiber's default BodyLimit
+const DefaultBufferedBodyLimit = 4 * 1024 * 1024
+
+// NewBufferedBody creates middleware that reads the whole request body into
+// memory, so every middleware after it and the handler can read it, e.g. to
+// log it or verify a signature before the handler parses it. Read it with
+// c.Body(), c.BodyRaw() or BodyReader; c.Request().BodyStream() is nil
+// afterwards. Bodies larger than maxBytes (default DefaultBufferedBodyLimit)
+// get a 413 response envelope without being buffered.
+//
+// Without fiber's Config.StreamRequestBody the server has already buffered
+// the body, up to Config.BodyLimit, and this only enforces maxBytes. With
+// streaming enabled, the body arrives as a stream that can be read once and
+// isn't bounded by BodyLimit; register this on the routes that need it
+// buffered.
+func NewBufferedBody(maxBytes int) fiber.Handler {
+	if maxBytes <= 0 {
+		maxBytes = DefaultBufferedBodyLimit
+	}
+	message := fmt.Sprintf("Request body must not exceed %d bytes", maxBytes)
+
+	return func(c fiber.Ctx) error {
+		if ok, err := bufferBody(c, maxBytes, message); !ok {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// bufferBody reads a streamed request body into memory, enforcing maxBytes.
+// When the body is rejected it sends the error response and returns false.
+func bufferBody(c fiber.Ctx, maxBytes int, message string) (bool, error) {
+	req := c.Request()
+	if !req.IsBodyStream() {
+		if req.Header.ContentLength() > maxBytes || len(req.Body()) > maxBytes {
+			return false, response.PayloadTooLarge(c, message)
+		}
+		return true, nil
+	}
+
+	// The rest of a rejected stream is left unread, so the connection
+	// can't be reused for another request
+	if req.Header.ContentLength() > maxBytes {
+		c.RequestCtx().SetConnectionClose()
+		return false, response.PayloadTooLarge(c, message)
+	}
+
+	// Read one byte past the limit to tell a body of exactly maxBytes from a
+	// larger one
+	body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(maxBytes)+1))
+	if err != nil {
+		c.RequestCtx().SetConnectionClose()
+		return false, response.BadRequest(c, "Failed to read request body")
+	}
+	if len(body) > maxBytes {
+		c.RequestCtx().SetConnectionClose()
+		return false, response.PayloadTooLarge(c, message)
+	}
+
+	req.SetBody(body)
+	return true, nil
+}
+
+// BodyReader returns a reader over the raw request body, for code that
+// expects an io.Reader. Each call starts from the beginning of the body.
+func BodyReader(c fiber.Ctx) io.Reader {
+	return bytes.NewReader(c.BodyRaw())
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pengenjago/fibox/cache"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// cachedResponse is a stored handler response. It's kept as a JSON string so
+// it survives any Cache backend, including RedisCache's JSON round-trip.
+type cachedResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType"`
+	Body        []byte `json:"body"`
+}
+
+// NewCacheMiddleware creates middleware that caches successful (2xx) GET
+// responses in c for ttl. keyFunc builds the cache key, default CacheKey();
+// an empty key bypasses the cache. A request with Cache-Control: no-cache
+// skips the lookup but refreshes the entry, and no-store bypasses the cache
+// entirely. Responses carry an X-Cache header of HIT or MISS.
+//
+// Responses are shared by every request with the same key. A custom keyFunc
+// for per-user responses must include the user, as CacheKey does.
+func NewCacheMiddleware(c cache.Cache, ttl time.Duration, keyFunc func(fiber.Ctx) string) fiber.Handler {
+	if keyFunc == nil {
+		keyFunc = CacheKey()
+	}
+
+	return func(ctx fiber.Ctx) error {
+		if ctx.Method() != fiber.MethodGet {
+			return ctx.Next()
+		}
+
+		cacheControl := strings.ToLower(ctx.Get(fiber.HeaderCacheControl))
+		if strings.Contains(cacheControl, "no-store") {
+			return ctx.Next()
+		}
+
+		key := keyFunc(ctx)
+		if key == "" {
+			return ctx.Next()
+		}
+		if !strings.Contains(cacheControl, "no-cache") {
+			if cached, ok := lookupResponse(ctx, c, key); ok {
+				ctx.Set("X-Cache", "HIT")
+				if cached.ContentType != "" {
+					ctx.Set(fiber.HeaderContentType, cached.ContentType)
+				}
+				return ctx.Status(cached.Status).Send(cached.Body)
+			}
+		}
+
+		ctx.Set("X-Cache", "MISS")
+		if err := ctx.Next(); err != nil {
+			return err
+		}
+
+		status := ctx.Response().StatusCode()
+		if status < fiber.StatusOK || status >= fiber.StatusMultipleChoices {
+			return nil
+		}
+
+		data, err := json.Marshal(cachedResponse{
+			Status:      status,
+			ContentType: string(ctx.Response().Header.ContentType()),
+			Body:        ctx.Response().Body(),
+		})
+		if err != nil {
+			return nil
+		}
+
+		// Caching is best effort; the response has already been produced
+		_ = c.SetWithTTL(ctx.Context(), key, string(data), ttl)
+		return nil
+	}
+}
+
+// lookupResponse returns the response stored under key, if any
+func lookupResponse(ctx fiber.Ctx, c cache.Cache, key string) (cachedResponse, bool) {
+	var cached cachedResponse
+
+	value, ok := c.Get(ctx.Context(), key)
+	if !ok {
+		return cached, false
+	}
+
+	data, ok := value.(string)
+	if !ok || json.Unmarshal([]byte(data), &cached) != nil {
+		return cached, false
+	}
+	return cached, true
+}
+
+// CacheKey returns a key function for NewCacheMiddleware built from the path,
+// the query parameters in sorted order, the values of varyHeaders and the
+// authenticated user ID, so one user's response is never served to another.
+// A request with an Authorization header but no user ID, e.g. when the cache
+// middleware runs before AuthMiddleware, gets an empty key and isn't cached.
+func CacheKey(varyHeaders ...string) func(fiber.Ctx) string {
+	return func(c fiber.Ctx) string {
+		userID, authenticated := UserID(c)
+		if !authenticated && c.Get(fiber.HeaderAuthorization) != "" {
+			return ""
+		}
+
+		var b strings.Builder
+		b.WriteString("http:")
+		b.WriteString(c.Path())
+
+		if query, err := url.ParseQuery(string(c.Request().URI().QueryString())); err == nil && len(query) > 0 {
+			b.WriteByte('?')
+			b.WriteString(query.Encode())
+		}
+
+		for _, header := range varyHeaders {
+			b.WriteString("|")
+			b.WriteString(header)
+			b.WriteByte('=')
+			b.WriteString(c.Get(header))
+		}
+
+		if authenticated {
+			b.WriteString("|user=")
+			b.WriteString(userID)
+		}
+
+		return b.String()
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/pengenjago/fibox/cache"
+)
+
+// TestCacheMiddlewareKeepsUsersApart checks that a cached per-user response
+// isn't served to another user
+func TestCacheMiddlewareKeepsUsersApart(t *testing.T) {
+	app := fiber.New()
+	app.Get("/me",
+		func(c fiber.Ctx) error {
+			if user := c.Get("X-User"); user != "" {
+				SetUserID(c, user)
+			}
+			return c.Next()
+		},
+		NewCacheMiddleware(cache.NewLRUCache(100), time.Minute, nil),
+		func(c fiber.Ctx) error {
+			userID, _ := UserID(c)
+			return c.SendString("profile of " + userID)
+		},
+	)
+
+	for _, tt := range []struct {
+		user, want, xCache string
+	}{
+		{"alice", "profile of alice", "MISS"},
+		{"bob", "profile of bob", "MISS"},
+		{"alice", "profile of alice", "HIT"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("X-User", tt.user)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != tt.want || resp.Header.Get("X-Cache") != tt.xCache {
+			t.Fatalf("%s: got %q (%s), want %q (%s)", tt.user, body, resp.Header.Get("X-Cache"), tt.want, tt.xCache)
+		}
+	}
+}
+
+// TestCacheMiddlewareSkipsUnresolvedAuthorization checks that a request with
+// credentials but no user ID, e.g. with the cache before the auth middleware,
+// is neither served from nor stored in the cache
+func TestCacheMiddlewareSkipsUnresolvedAuthorization(t *testing.T) {
+	store := cache.NewLRUCache(100)
+	calls := 0
+	app := fiber.New()
+	app.Get("/me", NewCacheMiddleware(store, time.Minute, nil), func(c fiber.Ctx) error {
+		calls++
+		return c.SendString(c.Get(fiber.HeaderAuthorization))
+	})
+
+	for _, token := range []string{"Bearer alice", "Bearer bob"} {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(fiber.HeaderAuthorization, token)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != token {
+			t.Fatalf("got %q, want %q", body, token)
+		}
+	}
+
+	if calls != 2 || store.Len(t.Context()) != 0 {
+		t.Fatalf("handler calls = %d, cached entries = %d; want 2 and 0", calls, store.Len(t.Context()))
+	}
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/compress"
+	"github.com/gofiber/fiber/v3/middleware/etag"
+	"github.com/valyala/fasthttp"
+)
+
+// DefaultCompressionMinLength is the smallest body NewCompression compresses
+// by default; below it the encoding overhead outweighs the savings
+const DefaultCompressionMinLength = 1024
+
+// DefaultCompressionExcludedTypes are media types already compressed, which
+// NewCompression leaves alone by default
+var DefaultCompressionExcludedTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/", "audio/", "font/woff", "font/woff2",
+	"application/zip", "application/gzip", "application/x-gzip",
+	"application/pdf", "application/octet-stream",
+}
+
+// CompressionConfig holds response compression configuration
+type CompressionConfig struct {
+	// Level is fiber's compression level, default compress.LevelDefault;
+	// compress.LevelDisabled turns the middleware off
+	Level compress.Level
+	// MinLength skips bodies shorter than this many bytes, default
+	// DefaultCompressionMinLength
+	MinLength int
+	// ExcludedContentTypes are media types not compressed; entries ending in
+	// "/" match the whole type, e.g. "video/". Default
+	// DefaultCompressionExcludedTypes.
+	ExcludedContentTypes []string
+	// SkipPaths lists exact paths that aren't compressed
+	SkipPaths []string
+}
+
+// compressionEncodings are the supported encodings, most preferred first
+var compressionEncodings = []string{"br", "gzip", "deflate", "zstd"}
+
+// compressionLevels are the fasthttp levels of a compress.Level
+type compressionLevels struct {
+	brotli, zstd, other int
+}
+
+// NewCompression creates middleware that compresses responses with brotli,
+// gzip, deflate or zstd, whichever the client's Accept-Encoding gives the
+// highest q-value (ties go in that order; q=0 refuses an encoding), and adds
+// Vary: Accept-Encoding. Like fiber's compress middleware it skips HEAD
+// requests, bodiless and range responses, Cache-Control: no-transform and
+// bodies that are already encoded; it also skips streamed bodies, bodies
+// under MinLength and excluded content types.
+func NewCompression(config CompressionConfig) fiber.Handler {
+	var levels compressionLevels
+
+	switch config.Level {
+	case compress.LevelDefault:
+		levels = compressionLevels{fasthttp.CompressBrotliDefaultCompression, fasthttp.CompressZstdDefault, fasthttp.CompressDefaultCompression}
+	case compress.LevelBestSpeed:
+		levels = compressionLevels{fasthttp.CompressBrotliBestSpeed, fasthttp.CompressZstdBestSpeed, fasthttp.CompressBestSpeed}
+	case compress.LevelBestCompression:
+		levels = compressionLevels{fasthttp.CompressBrotliBestCompression, fasthttp.CompressZstdBestCompression, fasthttp.CompressBestCompression}
+	default:
+		return func(c fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	minLength := config.MinLength
+	if minLength <= 0 {
+		minLength = DefaultCompressionMinLength
+	}
+
+	excluded := config.ExcludedContentTypes
+	if excluded == nil {
+		excluded = DefaultCompressionExcludedTypes
+	}
+
+	skip := make(map[string]struct{}, len(config.SkipPaths))
+	for _, path := range config.SkipPaths {
+		skip[path] = struct{}{}
+	}
+
+	return func(c fiber.Ctx) error {
+		if _, ok := skip[c.Path()]; ok {
+			return c.Next()
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		// Responses vary by Accept-Encoding even when this one isn't compressed
+		defer c.Vary(fiber.HeaderAcceptEncoding)
+
+		if skipCompression(c, minLength, excluded) {
+			return nil
+		}
+
+		encoding := negotiateEncoding(c.Get(fiber.HeaderAcceptEncoding))
+		if encoding == "" {
+			return nil
+		}
+		body := c.Response().Body()
+		var compressed []byte
+		switch encoding {
+		case "br":
+			compressed = fasthttp.AppendBrotliBytesLevel(nil, body, levels.brotli)
+		case "zstd":
+			compressed = fasthttp.AppendZstdBytesLevel(nil, body, levels.zstd)
+		case "gzip":
+			compressed = fasthttp.AppendGzipBytesLevel(nil, body, levels.other)
+		case "deflate":
+			compressed = fasthttp.AppendDeflateBytesLevel(nil, body, levels.other)
+		}
+		c.Response().SetBodyRaw(compressed)
+		c.Set(fiber.HeaderContentEncoding, encoding)
+
+		// A strong ETag names the uncompressed bytes, so recompute it
+		if tag := c.GetRespHeader(fiber.HeaderETag); tag != "" && !strings.HasPrefix(tag, "W/") {
+			c.Set(fiber.HeaderETag, string(etag.Generate(c.Response().Body())))
+		}
+
+		return nil
+	}
+}
+
+// skipCompression reports whether the response must be sent as is
+func skipCompression(c fiber.Ctx, minLength int, excluded []string) bool {
+	if c.Method() == fiber.MethodHead || c.Get(fiber.HeaderRange) != "" {
+		return true
+	}
+
+	resp := c.Response()
+	switch status := resp.StatusCode(); {
+	case status < 200, status == fiber.StatusNoContent, status == fiber.StatusResetContent,
+		status == fiber.StatusNotModified, status == fiber.StatusPartialContent:
+		return true
+	}
+
+	// Reading a streamed body would buffer all of it, e.g. server-sent events
+	if resp.IsBodyStream() || len(resp.Body()) < minLength {
+		return true
+	}
+
+	if c.GetRespHeader(fiber.HeaderContentEncoding) != "" ||
+		hasHeaderToken(c.Get(fiber.HeaderCacheControl), "no-transform") ||
+		hasHeaderToken(c.GetRespHeader(fiber.HeaderCacheControl), "no-transform") {
+		return true
+	}
+
+	contentType := c.GetRespHeader(fiber.HeaderContentType)
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	for _, excludedType := range excluded {
+		if contentType == excludedType || (strings.HasSuffix(excludedType, "/") && strings.HasPrefix(contentType, excludedType)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// negotiateEncoding returns the supported encoding with the highest q-value
+// in an Accept-Encoding header, "" if none is acceptable. A "*" entry covers
+// the encodings not listed by name.
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := make(map[string]float64, len(compressionEncodings))
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		if name == "*" {
+			wildcard = q
+		} else {
+			qualities[name] = q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range compressionEncodings {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// hasHeaderToken reports whether the comma-separated header value contains
+// token, case-insensitively
+func hasHeaderToken(value, token string) bool {
+	for _, part := range strings.Split(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(part), token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/compress"
+)
+
+func TestCompressionHonorsQValues(t *testing.T) {
+	body := strings.Repeat("compress me ", 200)
+	app := fiber.New()
+	app.Use(NewCompression(CompressionConfig{Level: compress.LevelDefault}))
+	app.Get("/", func(c fiber.Ctx) error {
+		return c.SendString(body)
+	})
+
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{"br;q=0, gzip", "gzip"},
+		{"gzip;q=0.5, br;q=0.8", "br"},
+		{"deflate, gzip;q=0.9", "deflate"},
+		{"GZIP;Q=1, br;q=0.1", "gzip"},
+		{"gzip, deflate, br, zstd", "br"},
+		{"zstd", "zstd"},
+		{"*", "br"},
+		{"*;q=0.5, gzip", "gzip"},
+		{"*;q=0", ""},
+		{"br;q=0, *", "gzip"},
+		{"identity", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			req.Header.Set(fiber.HeaderAcceptEncoding, tt.acceptEncoding)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if got := resp.Header.Get(fiber.HeaderContentEncoding); got != tt.want {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.want)
+			}
+
+			var r io.Reader = resp.Body
+			switch tt.want {
+			case "gzip":
+				if r, err = gzip.NewReader(resp.Body); err != nil {
+					t.Fatal(err)
+				}
+			case "":
+			default:
+				return
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != body {
+				t.Fatalf("body isn't the original response")
+			}
+		})
+	}
+}
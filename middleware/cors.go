@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// CORSConfig holds CORS configuration
+type CORSConfig struct {
+	// AllowOrigins lists allowed origins. "*" allows any origin and a
+	// "https://*.example.com" entry allows any subdomain. Default ["*"].
+	AllowOrigins []string
+	// AllowMethods lists methods allowed in preflight responses, default GET, POST, PUT, PATCH, DELETE and HEAD
+	AllowMethods []string
+	// AllowHeaders lists request headers allowed in preflight responses.
+	// When empty, the headers requested by the browser are echoed back.
+	AllowHeaders []string
+	// ExposeHeaders lists response headers readable by the browser
+	ExposeHeaders []string
+	// AllowCredentials allows cookies and auth headers. It is never sent for
+	// origins matched by "*", since browsers reject that combination.
+	AllowCredentials bool
+	// MaxAge is how long, in seconds, browsers may cache a preflight response; zero omits it
+	MaxAge int
+}
+
+// NewCORS creates CORS middleware. Preflight OPTIONS requests are answered
+// directly with 204 No Content; other requests continue down the chain.
+func NewCORS(config CORSConfig) fiber.Handler {
+	allowOrigins := config.AllowOrigins
+	if len(allowOrigins) == 0 {
+		allowOrigins = []string{"*"}
+	}
+
+	allowMethods := config.AllowMethods
+	if len(allowMethods) == 0 {
+		allowMethods = []string{
+			fiber.MethodGet,
+			fiber.MethodPost,
+			fiber.MethodPut,
+			fiber.MethodPatch,
+			fiber.MethodDelete,
+			fiber.MethodHead,
+		}
+	}
+
+	methods := strings.Join(allowMethods, ", ")
+	headers := strings.Join(config.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(config.MaxAge)
+
+	return func(c fiber.Ctx) error {
+		origin := c.Get(fiber.HeaderOrigin)
+		preflight := c.Method() == fiber.MethodOptions && c.Get(fiber.HeaderAccessControlRequestMethod) != ""
+
+		// Responses differ per origin, so caches must key on it
+		c.Vary(fiber.HeaderOrigin)
+
+		allowedOrigin, wildcard := matchOrigin(allowOrigins, origin)
+		if origin == "" || allowedOrigin == "" {
+			if preflight {
+				return c.SendStatus(fiber.StatusNoContent)
+			}
+			return c.Next()
+		}
+
+		c.Set(fiber.HeaderAccessControlAllowOrigin, allowedOrigin)
+		if config.AllowCredentials && !wildcard {
+			c.Set(fiber.HeaderAccessControlAllowCredentials, "true")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				c.Set(fiber.HeaderAccessControlExposeHeaders, exposeHeaders)
+			}
+			return c.Next()
+		}
+
+		c.Set(fiber.HeaderAccessControlAllowMethods, methods)
+		if headers != "" {
+			c.Set(fiber.HeaderAccessControlAllowHeaders, headers)
+		} else if requested := c.Get(fiber.HeaderAccessControlRequestHeaders); requested != "" {
+			c.Set(fiber.HeaderAccessControlAllowHeaders, requested)
+		}
+		if config.MaxAge > 0 {
+			c.Set(fiber.HeaderAccessControlMaxAge, maxAge)
+		}
+
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// matchOrigin returns the Access-Control-Allow-Origin value for origin, or ""
+// if it isn't allowed, and whether it was matched by the "*" wildcard
+func matchOrigin(allowOrigins []string, origin string) (string, bool) {
+	for _, allowed := range allowOrigins {
+		switch {
+		case allowed == "*":
+			return "*", true
+		case strings.EqualFold(allowed, origin):
+			return origin, false
+		case strings.Contains(allowed, "://*."):
+			// "https://*.example.com" matches "https://api.example.com"
+			scheme, domain, _ := strings.Cut(allowed, "://*.")
+			if strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(origin, "."+domain) {
+				return origin, false
+			}
+		}
+	}
+	return "", false
+}
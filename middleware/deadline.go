@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pengenjago/fibox/response"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	// HeaderRequestDeadline carries an absolute deadline as an RFC 3339
+	// timestamp, e.g. "2025-01-02T15:04:05.250Z"
+	HeaderRequestDeadline = "X-Request-Deadline"
+	// HeaderGRPCTimeout carries the remaining budget in gRPC's format: up to
+	// 8 digits followed by a unit of H, M, S, m (ms), u (µs) or n (ns), e.g. "1500m"
+	HeaderGRPCTimeout = "Grpc-Timeout"
+)
+
+// grpcTimeoutUnits maps grpc-timeout unit suffixes to durations
+var grpcTimeoutUnits = map[byte]time.Duration{
+	'H': time.Hour,
+	'M': time.Minute,
+	'S': time.Second,
+	'm': time.Millisecond,
+	'u': time.Microsecond,
+	'n': time.Nanosecond,
+}
+
+// NewDeadline creates middleware that bounds the rest of the chain by the
+// caller's deadline, read from X-Request-Deadline or grpc-timeout (the
+// earlier wins when both are sent; malformed values are ignored). The
+// deadline is set on c.Context(), so context-aware calls such as
+// HTTPClient.GetCtx inherit it, and an HTTPClient with PropagateDeadline
+// forwards the remaining budget downstream. A request whose deadline has
+// already passed gets 504 without running the chain, as does one whose chain
+// returns an error wrapping context.DeadlineExceeded; a handler that finishes
+// after the deadline without such an error keeps its response.
+func NewDeadline() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		deadline, ok := requestDeadline(c)
+		if !ok {
+			return c.Next()
+		}
+
+		if !time.Now().Before(deadline) {
+			return response.GatewayTimeout(c, "Request deadline exceeded")
+		}
+
+		parent := c.Context()
+		ctx, cancel := context.WithDeadline(parent, deadline)
+		defer cancel()
+
+		c.SetContext(ctx)
+		err := c.Next()
+		c.SetContext(parent)
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			return response.GatewayTimeout(c, "Request deadline exceeded")
+		}
+
+		return err
+	}
+}
+
+// requestDeadline returns the earliest deadline carried by the request headers
+func requestDeadline(c fiber.Ctx) (time.Time, bool) {
+	var deadline time.Time
+
+	if value := c.Get(HeaderRequestDeadline); value != "" {
+		if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			deadline = t
+		}
+	}
+
+	if timeout, ok := parseGRPCTimeout(c.Get(HeaderGRPCTimeout)); ok {
+		if t := time.Now().Add(timeout); deadline.IsZero() || t.Before(deadline) {
+			deadline = t
+		}
+	}
+
+	return deadline, !deadline.IsZero()
+}
+
+// parseGRPCTimeout parses a grpc-timeout header value such as "1500m"
+func parseGRPCTimeout(value string) (time.Duration, bool) {
+	if len(value) < 2 || len(value) > 9 {
+		return 0, false
+	}
+
+	unit, ok := grpcTimeoutUnits[value[len(value)-1]]
+	if !ok {
+		return 0, false
+	}
+
+	amount, err := strconv.ParseUint(value[:len(value)-1], 10, 64)
+	if err != nil || amount > uint64(math.MaxInt64/unit) {
+		return 0, false
+	}
+	return time.Duration(amount) * unit, true
+}
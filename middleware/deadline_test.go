@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestDeadline(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		value   func() string
+		handler fiber.Handler
+		status  int
+		body    string
+	}{
+		{"expired on arrival", HeaderRequestDeadline, func() string {
+			return time.Now().Add(-time.Second).Format(time.RFC3339Nano)
+		}, func(c fiber.Ctx) error {
+			t.Error("handler ran for an expired deadline")
+			return c.SendString("ran")
+		}, fiber.StatusGatewayTimeout, ""},
+		{"returns the deadline error", HeaderRequestDeadline, func() string {
+			return time.Now().Add(10 * time.Millisecond).Format(time.RFC3339Nano)
+		}, func(c fiber.Ctx) error {
+			<-c.Context().Done()
+			return fmt.Errorf("upstream: %w", c.Context().Err())
+		}, fiber.StatusGatewayTimeout, ""},
+		{"succeeds after the deadline", HeaderGRPCTimeout, func() string {
+			return "10m"
+		}, func(c fiber.Ctx) error {
+			time.Sleep(50 * time.Millisecond)
+			return c.SendString("late")
+		}, fiber.StatusOK, "late"},
+		{"no deadline", "", nil, func(c fiber.Ctx) error {
+			if _, ok := c.Context().Deadline(); ok {
+				t.Error("context has a deadline without a header")
+			}
+			return c.SendString("ok")
+		}, fiber.StatusOK, "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", NewDeadline(), tt.handler)
+
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value())
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.body != "" {
+				body, _ := io.ReadAll(resp.Body)
+				if string(body) != tt.body {
+					t.Fatalf("body = %q, want %q", body, tt.body)
+				}
+			}
+		})
+	}
+}
+
+func TestDeadlineHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    time.Duration
+		ok      bool
+	}{
+		{"request deadline", map[string]string{HeaderRequestDeadline: "+2s"}, 2 * time.Second, true},
+		{"grpc seconds", map[string]string{HeaderGRPCTimeout: "3S"}, 3 * time.Second, true},
+		{"grpc milliseconds", map[string]string{HeaderGRPCTimeout: "1500m"}, 1500 * time.Millisecond, true},
+		{"grpc hours", map[string]string{HeaderGRPCTimeout: "1H"}, time.Hour, true},
+		{"earlier wins", map[string]string{HeaderRequestDeadline: "+5s", HeaderGRPCTimeout: "1S"}, time.Second, true},
+		{"malformed deadline", map[string]string{HeaderRequestDeadline: "tomorrow"}, 0, false},
+		{"malformed grpc unit", map[string]string{HeaderGRPCTimeout: "10x"}, 0, false},
+		{"grpc too many digits", map[string]string{HeaderGRPCTimeout: "123456789S"}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got time.Duration
+			var ok bool
+			app := fiber.New()
+			app.Get("/", NewDeadline(), func(c fiber.Ctx) error {
+				var deadline time.Time
+				deadline, ok = c.Context().Deadline()
+				got = time.Until(deadline)
+				return nil
+			})
+
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			for name, value := range tt.headers {
+				if offset, found := strings.CutPrefix(value, "+"); found {
+					d, _ := time.ParseDuration(offset)
+					value = time.Now().Add(d).Format(time.RFC3339Nano)
+				}
+				req.Header.Set(name, value)
+			}
+			if _, err := app.Test(req); err != nil {
+				t.Fatal(err)
+			}
+
+			if ok != tt.ok {
+				t.Fatalf("deadline set = %v, want %v", ok, tt.ok)
+			}
+			if ok && (got > tt.want || got < tt.want-time.Second) {
+				t.Fatalf("deadline in %v, want about %v", got, tt.want)
+			}
+		})
+	}
+}
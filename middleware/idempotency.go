@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pengenjago/fibox/cache"
+	"github.com/pengenjago/fibox/logging"
+	"github.com/pengenjago/fibox/response"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// HeaderIdempotencyKey is the request header carrying the idempotency key
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// idempotencyLockTTL bounds how long an in-flight key stays locked if the
+// process dies before releasing it
+var idempotencyLockTTL = time.Minute
+
+// NewIdempotency creates middleware that makes POST, PUT, PATCH and DELETE
+// requests carrying an Idempotency-Key header safe to replay. The first
+// response for a key is stored in c for ttl and returned for later requests
+// with the same key, method, path and user, marked with an
+// Idempotent-Replayed: true header. A request arriving while the same key is
+// still being handled gets 409 Conflict. 5xx responses and handler errors are
+// not stored, so the client can retry them.
+//
+// In-flight keys are held with a cache.Lock, so c must support one
+// (RedisCache, LRUCache, or a Namespaced or TieredCache over them). It
+// panics if ttl isn't positive.
+func NewIdempotency(c cache.Cache, ttl time.Duration) fiber.Handler {
+	if ttl <= 0 {
+		panic("middleware: NewIdempotency ttl must be positive")
+	}
+	lock := cache.NewLock(c)
+
+	return func(ctx fiber.Ctx) error {
+		switch ctx.Method() {
+		case fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete:
+		default:
+			return ctx.Next()
+		}
+
+		idempotencyKey := ctx.Get(HeaderIdempotencyKey)
+		if idempotencyKey == "" {
+			return ctx.Next()
+		}
+
+		key := "idempotency:" + ctx.Method() + " " + ctx.Path() + "|" + GetAuthInfo(ctx).UserID + "|" + idempotencyKey
+		if cached, ok := lookupResponse(ctx, c, key); ok {
+			return replayResponse(ctx, cached)
+		}
+
+		// The lock is owned by this request: if the handler outlives the TTL
+		// and another request takes the key, releasing leaves that one alone
+		acquired, release, err := lock.TryLock(ctx.Context(), key, idempotencyLockTTL)
+		if err != nil {
+			logging.ErrorWithFields("Idempotency lock failed", err,
+				map[string]interface{}{
+					"key": idempotencyKey,
+				})
+			return response.InternalError(ctx, "Failed to process idempotency key")
+		}
+		if !acquired {
+			return response.Conflict(ctx, "A request with this idempotency key is already in progress")
+		}
+		defer release()
+
+		// Another request may have finished between the lookup and the lock
+		if cached, ok := lookupResponse(ctx, c, key); ok {
+			return replayResponse(ctx, cached)
+		}
+
+		if err := ctx.Next(); err != nil {
+			return err
+		}
+
+		status := ctx.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			return nil
+		}
+
+		data, err := json.Marshal(cachedResponse{
+			Status:      status,
+			ContentType: string(ctx.Response().Header.ContentType()),
+			Body:        ctx.Response().Body(),
+		})
+		if err != nil {
+			return nil
+		}
+
+		// The replay window is a promise to the client, so it isn't jittered
+		if err := c.SetWithTTL(cache.WithTTLJitter(ctx.Context(), 0), key, string(data), ttl); err != nil {
+			logging.ErrorWithFields("Idempotency response store failed", err,
+				map[string]interface{}{
+					"key": idempotencyKey,
+				})
+		}
+		return nil
+	}
+}
+
+// replayResponse writes a stored response
+func replayResponse(ctx fiber.Ctx, cached cachedResponse) error {
+	ctx.Set("Idempotent-Replayed", "true")
+	if cached.ContentType != "" {
+		ctx.Set(fiber.HeaderContentType, cached.ContentType)
+	}
+	return ctx.Status(cached.Status).Send(cached.Body)
+}
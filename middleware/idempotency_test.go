@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/pengenjago/fibox/cache"
+)
+
+// TestIdempotencyKeepsTakenOverLock lets a request outlive the lock TTL so a
+// second request takes the key over. When the first one finishes, it must
+// not release the second one's lock.
+func TestIdempotencyKeepsTakenOverLock(t *testing.T) {
+	defer func(ttl time.Duration) { idempotencyLockTTL = ttl }(idempotencyLockTTL)
+	idempotencyLockTTL = 50 * time.Millisecond
+
+	releaseA, releaseB := make(chan struct{}), make(chan struct{})
+	app := fiber.New()
+	app.Post("/orders", NewIdempotency(cache.NewLRUCache(100), time.Hour), func(c fiber.Ctx) error {
+		switch c.Get("X-Request") {
+		case "a":
+			<-releaseA
+			// Not stored, so later requests go through the lock again
+			return c.SendStatus(fiber.StatusServiceUnavailable)
+		case "b":
+			<-releaseB
+		}
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	send := func(name string) chan int {
+		status := make(chan int, 1)
+		go func() {
+			req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+			req.Header.Set(HeaderIdempotencyKey, "order-1")
+			req.Header.Set("X-Request", name)
+			resp, err := app.Test(req, fiber.TestConfig{Timeout: 5 * time.Second})
+			if err != nil {
+				t.Error(err)
+				status <- 0
+				return
+			}
+			status <- resp.StatusCode
+		}()
+		return status
+	}
+
+	a := send("a")
+	time.Sleep(2 * idempotencyLockTTL)
+	b := send("b")
+	time.Sleep(idempotencyLockTTL / 2)
+
+	close(releaseA)
+	if got := <-a; got != fiber.StatusServiceUnavailable {
+		t.Fatalf("first request: status %d, want 503", got)
+	}
+
+	// b still holds the key
+	if got := <-send("c"); got != fiber.StatusConflict {
+		t.Fatalf("request during b: status %d, want 409", got)
+	}
+
+	close(releaseB)
+	if got := <-b; got != fiber.StatusCreated {
+		t.Fatalf("second request: status %d, want 201", got)
+	}
+}
+
+func TestIdempotencyRejectsNonPositiveTTL(t *testing.T) {
+	for _, ttl := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewIdempotency(%v) didn't panic", ttl)
+				}
+			}()
+			NewIdempotency(cache.NewLRUCache(10), ttl)
+		}()
+	}
+}
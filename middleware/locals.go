@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// LocalsKey is the type of the Locals keys set by this package. Being a
+// distinct type, they can't collide with application keys, which should use
+// their own types too. Prefer the typed getters and setters below over
+// reading the keys directly.
+//
+// Earlier releases used plain string keys such as c.Locals("userID"). For one
+// release the values are also stored under those strings, and the getters
+// fall back to them, so existing code keeps working while it migrates.
+type LocalsKey string
+
+// Locals keys set by this package
+const (
+	UserIDKey         LocalsKey = "userID"
+	EmailKey          LocalsKey = "email"
+	RoleKey           LocalsKey = "role"
+	TokenExpiryKey    LocalsKey = "tokenExpiry"
+	RequestIDKey      LocalsKey = "requestID"
+	APIKeyIdentityKey LocalsKey = "apiKeyIdentity"
+)
+
+// SetUserID stores the authenticated user ID, e.g. from a custom
+// authentication middleware
+func SetUserID(c fiber.Ctx, userID string) {
+	setLocal(c, UserIDKey, userID)
+}
+
+// UserID returns the authenticated user ID, false if none is set
+func UserID(c fiber.Ctx) (string, bool) {
+	return localString(c, UserIDKey)
+}
+
+// SetEmail stores the authenticated user's email
+func SetEmail(c fiber.Ctx, email string) {
+	setLocal(c, EmailKey, email)
+}
+
+// Email returns the authenticated user's email, false if none is set
+func Email(c fiber.Ctx) (string, bool) {
+	return localString(c, EmailKey)
+}
+
+// SetRole stores the authenticated user's role, checked by RequireRole
+func SetRole(c fiber.Ctx, role string) {
+	setLocal(c, RoleKey, role)
+}
+
+// Role returns the authenticated user's role, false if none is set
+func Role(c fiber.Ctx) (string, bool) {
+	return localString(c, RoleKey)
+}
+
+// SetTokenExpiry stores when the request's token expires, read by
+// TokenExpiry and TokenTimeLeft
+func SetTokenExpiry(c fiber.Ctx, expiry time.Time) {
+	setLocal(c, TokenExpiryKey, expiry)
+}
+
+// setLocal stores value under key and, until the legacy keys are removed,
+// under its plain string
+func setLocal(c fiber.Ctx, key LocalsKey, value any) {
+	c.Locals(key, value)
+	c.Locals(string(key), value)
+}
+
+// local returns the value stored under key, falling back to its plain string
+// for values set by code not yet using LocalsKey
+func local(c fiber.Ctx, key LocalsKey) any {
+	if value := c.Locals(key); value != nil {
+		return value
+	}
+	return c.Locals(string(key))
+}
+
+// localString returns the string stored under key, false if it is unset or empty
+func localString(c fiber.Ctx, key LocalsKey) (string, bool) {
+	value, ok := local(c, key).(string)
+	return value, ok && value != ""
+}
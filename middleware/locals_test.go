@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestLocalsLegacyStringKeys(t *testing.T) {
+	app := fiber.New()
+	app.Get("/set", NewRequestID(), func(c fiber.Ctx) error {
+		SetUserID(c, "user123")
+		if got, _ := c.Locals("userID").(string); got != "user123" {
+			t.Errorf(`c.Locals("userID") = %q, want "user123"`, got)
+		}
+		if got, _ := c.Locals("requestID").(string); got == "" || got != GetRequestID(c) {
+			t.Errorf(`c.Locals("requestID") = %q, want %q`, got, GetRequestID(c))
+		}
+		return nil
+	})
+	app.Get("/legacy", func(c fiber.Ctx) error {
+		c.Locals("userID", "user456")
+		if got, ok := UserID(c); !ok || got != "user456" {
+			t.Errorf("UserID = %q, %v, want the legacy user456", got, ok)
+		}
+		return nil
+	})
+
+	for _, path := range []string{"/set", "/legacy"} {
+		if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pengenjago/fibox/logging"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/rs/zerolog"
+)
+
+// RequestLoggerConfig holds request logging configuration
+type RequestLoggerConfig struct {
+	// SkipPaths lists exact paths that aren't logged, e.g. "/health"
+	SkipPaths []string
+	// SuccessSampling, when set, samples the logs of 1xx-3xx responses, e.g.
+	// on high-traffic endpoints. 4xx and 5xx responses are always logged.
+	SuccessSampling *logging.SamplingRule
+}
+
+// NewRequestLogger creates middleware that logs each request's method, path,
+// status and latency once the handler chain completes, using the standard
+// logging.Field* keys. 4xx responses are logged as warnings and 5xx as errors.
+// Register it after NewRequestID to include the request_id field; user_id is
+// included for requests authenticated by AuthMiddleware.
+func NewRequestLogger(config RequestLoggerConfig) fiber.Handler {
+	skip := make(map[string]struct{}, len(config.SkipPaths))
+	for _, path := range config.SkipPaths {
+		skip[path] = struct{}{}
+	}
+
+	var sampler zerolog.Sampler
+	if config.SuccessSampling != nil {
+		sampler = logging.NewSampler(*config.SuccessSampling)
+	}
+
+	return func(c fiber.Ctx) error {
+		if _, ok := skip[c.Path()]; ok {
+			return c.Next()
+		}
+
+		start := time.Now()
+		err := c.Next()
+		latency := time.Since(start)
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// The error handler hasn't written the status yet
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		if status < fiber.StatusBadRequest && sampler != nil && !sampler.Sample(zerolog.InfoLevel) {
+			return err
+		}
+
+		fields := logging.RequestFields{
+			RequestID: GetRequestID(c),
+			UserID:    GetAuthInfo(c).UserID,
+			Method:    c.Method(),
+			Path:      c.Path(),
+			IP:        c.IP(),
+			Status:    status,
+			Latency:   latency,
+		}.Map()
+
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			logging.ErrorWithFields("HTTP request", err, fields)
+		case status >= fiber.StatusBadRequest:
+			logging.WarnWithFields("HTTP request", fields)
+		default:
+			logging.InfoWithFields("HTTP request", fields)
+		}
+
+		return err
+	}
+}
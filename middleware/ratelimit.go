@@ -5,6 +5,7 @@ import (
 
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/limiter"
+	"github.com/pengenjago/fibox/response"
 )
 
 // RateLimiterConfig holds rate limiter configuration
@@ -12,38 +13,126 @@ type RateLimiterConfig struct {
 	AuthLimit    int
 	GeneralLimit int
 	ChatLimit    int
+	// Windows for each limiter, default 1 minute
+	AuthWindow    time.Duration
+	GeneralWindow time.Duration
+	ChatWindow    time.Duration
+	// Storage is shared by all limiters, default in-memory
+	Storage fiber.Storage
 }
 
-// NewAuthRateLimiter creates rate limiter for auth endpoints
-func NewAuthRateLimiter(maxRequests int) fiber.Handler {
+// RateLimiters holds the handlers built by NewRateLimiters
+type RateLimiters struct {
+	Auth    fiber.Handler
+	General fiber.Handler
+	Chat    fiber.Handler
+}
+
+// LimiterConfig holds configuration for a single rate limiter
+type LimiterConfig struct {
+	// Max is the number of requests allowed per Window
+	Max int
+	// Window is how long requests are counted before the limit resets, default 1 minute
+	Window time.Duration
+	// Storage keeps the request counters, e.g. a Redis store shared by all
+	// instances. When nil, counters are kept in memory for this process only.
+	Storage fiber.Storage
+	// KeyGenerator returns the key requests are counted under, default c.IP()
+	KeyGenerator func(fiber.Ctx) string
+	// LimitReached handles rejected requests, default a 429 response envelope.
+	// The Retry-After header is already set to the seconds until reset.
+	LimitReached fiber.Handler
+}
+
+// NewRateLimiter creates a rate limiter keyed by client IP unless
+// config.KeyGenerator is set
+func NewRateLimiter(config LimiterConfig) fiber.Handler {
+	keyGenerator := config.KeyGenerator
+	if keyGenerator == nil {
+		keyGenerator = ipKey
+	}
+
+	limitReached := config.LimitReached
+	if limitReached == nil {
+		limitReached = tooManyRequests
+	}
+
+	window := config.Window
+	if window <= 0 {
+		window = 1 * time.Minute
+	}
+
 	return limiter.New(limiter.Config{
-		Max:        maxRequests,
-		Expiration: 1 * time.Minute,
-		KeyGenerator: func(c fiber.Ctx) string {
-			return c.IP()
-		},
-		LimitReached: func(c fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"success": false,
-				"error":   "Too many requests. Please try again later.",
-			})
-		},
+		Max:          config.Max,
+		Expiration:   window,
+		Storage:      config.Storage,
+		KeyGenerator: keyGenerator,
+		LimitReached: limitReached,
 	})
 }
 
+// ipKey is the default limiter key, the client IP
+func ipKey(c fiber.Ctx) string {
+	return c.IP()
+}
+
+// tooManyRequests is the default LimitReached handler
+func tooManyRequests(c fiber.Ctx) error {
+	return response.TooManyRequests(c, "Too many requests. Please try again later.")
+}
+
+// NewAuthRateLimiter creates rate limiter for auth endpoints
+func NewAuthRateLimiter(maxRequests int) fiber.Handler {
+	return NewRateLimiter(LimiterConfig{Max: maxRequests})
+}
+
 // NewGeneralRateLimiter creates rate limiter for general endpoints
 func NewGeneralRateLimiter(maxRequests int) fiber.Handler {
-	return limiter.New(limiter.Config{
-		Max:        maxRequests,
-		Expiration: 1 * time.Minute,
-		KeyGenerator: func(c fiber.Ctx) string {
-			return c.IP()
-		},
-		LimitReached: func(c fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"success": false,
-				"error":   "Too many requests. Please try again later.",
-			})
-		},
-	})
+	return NewRateLimiter(LimiterConfig{Max: maxRequests})
+}
+
+// NewChatRateLimiter creates rate limiter for chat endpoints
+func NewChatRateLimiter(maxRequests int) fiber.Handler {
+	return NewRateLimiter(LimiterConfig{Max: maxRequests})
+}
+
+// NewRateLimiters creates the auth, general and chat limiters from one config
+func NewRateLimiters(config RateLimiterConfig) RateLimiters {
+	return RateLimiters{
+		Auth: NewRateLimiter(LimiterConfig{
+			Max:     config.AuthLimit,
+			Window:  config.AuthWindow,
+			Storage: config.Storage,
+		}),
+		General: NewRateLimiter(LimiterConfig{
+			Max:     config.GeneralLimit,
+			Window:  config.GeneralWindow,
+			Storage: config.Storage,
+		}),
+		Chat: NewRateLimiter(LimiterConfig{
+			Max:     config.ChatLimit,
+			Window:  config.ChatWindow,
+			Storage: config.Storage,
+		}),
+	}
+}
+
+// NewUserRateLimiter creates a rate limiter keyed by the authenticated user,
+// falling back to client IP for anonymous requests. It must be registered
+// after AuthMiddleware or OptionalAuthMiddleware, otherwise every request is
+// counted by IP.
+func NewUserRateLimiter(config LimiterConfig) fiber.Handler {
+	if config.KeyGenerator == nil {
+		config.KeyGenerator = UserOrIPKey
+	}
+	return NewRateLimiter(config)
+}
+
+// UserOrIPKey returns a limiter key for the authenticated userID in Locals,
+// or for the client IP when the request is anonymous
+func UserOrIPKey(c fiber.Ctx) string {
+	if userID, ok := UserID(c); ok {
+		return "user:" + userID
+	}
+	return "ip:" + c.IP()
 }
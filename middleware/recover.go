@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/pengenjago/fibox/logging"
+	"github.com/pengenjago/fibox/response"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// RecoverConfig holds panic recovery configuration
+type RecoverConfig struct {
+	// DisableStackTrace omits the goroutine stack from the panic log
+	DisableStackTrace bool
+	// ExposeError sends the panic value as the response message instead of a
+	// generic one. Leave it off in production, it may leak internals.
+	ExposeError bool
+}
+
+// NewRecover creates middleware that turns handler panics into a logged 500
+// response envelope
+func NewRecover() fiber.Handler {
+	return NewRecoverWithConfig(RecoverConfig{})
+}
+
+// NewRecoverWithConfig creates panic recovery middleware with the given config.
+// Register it first so it covers every handler after it.
+func NewRecoverWithConfig(config RecoverConfig) fiber.Handler {
+	return func(c fiber.Ctx) (err error) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			panicErr, ok := recovered.(error)
+			if !ok {
+				panicErr = fmt.Errorf("%v", recovered)
+			}
+
+			fields := logging.RequestFields{
+				RequestID: GetRequestID(c),
+				UserID:    GetAuthInfo(c).UserID,
+				Method:    c.Method(),
+				Path:      c.Path(),
+			}.Map()
+			if !config.DisableStackTrace {
+				fields["stack"] = string(debug.Stack())
+			}
+			logging.ErrorWithFields("Recovered from panic", panicErr, fields)
+
+			message := "Internal server error"
+			if config.ExposeError {
+				message = panicErr.Error()
+			}
+			err = response.InternalError(c, message)
+		}()
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"github.com/pengenjago/fibox/logging"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header used to receive and echo request IDs
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied IDs so they can't bloat logs
+const maxRequestIDLength = 128
+
+// NewRequestID creates middleware that assigns each request an ID, reusing
+// the incoming X-Request-ID header when present. The ID is echoed in the
+// response, stored in Locals and attached to the context logger, so
+// logging.InfoCtx(c.Context(), ...) includes it as request_id.
+func NewRequestID() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		requestID := c.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+
+		c.Set(RequestIDHeader, requestID)
+		setLocal(c, RequestIDKey, requestID)
+		c.SetContext(logging.WithFields(c.Context(), map[string]interface{}{
+			logging.FieldRequestID: requestID,
+		}))
+
+		return c.Next()
+	}
+}
+
+// GetRequestID returns the request ID assigned by NewRequestID, or "" if none
+func GetRequestID(c fiber.Ctx) string {
+	requestID, _ := local(c, RequestIDKey).(string)
+	return requestID
+}
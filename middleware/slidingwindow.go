@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pengenjago/fibox/cache"
+	"github.com/pengenjago/fibox/logging"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// DefaultSlidingWindowCacheSize is the number of counters kept by the
+// in-memory cache of a sliding-window limiter; each client uses two
+const DefaultSlidingWindowCacheSize = 10000
+
+// SlidingWindowConfig holds configuration for a sliding-window rate limiter
+type SlidingWindowConfig struct {
+	// Max is the number of requests allowed in any Window-long span, default 5
+	Max int
+	// Window is the span requests are counted over, default 1 minute
+	Window time.Duration
+	// Cache keeps the request counters, e.g. a RedisCache shared by all
+	// instances, default an in-memory LRU of DefaultSlidingWindowCacheSize
+	// entries. Give limiters sharing a cache their own Namespaced view.
+	Cache cache.Cache
+	// KeyGenerator returns the key requests are counted under, default c.IP()
+	KeyGenerator func(fiber.Ctx) string
+	// LimitReached handles rejected requests, default a 429 response envelope.
+	// The Retry-After header is already set to the seconds until a request fits.
+	LimitReached fiber.Handler
+}
+
+// NewSlidingWindowLimiter creates a rate limiter that, unlike the fixed
+// window of NewRateLimiter, doesn't let a client send up to twice Max across
+// a window boundary. It counts requests per fixed window and weighs the
+// previous window's count by how much of it still overlaps the sliding
+// window, which approximates the limit over any Window-long span as if the
+// previous window's requests were evenly spread. Rejected requests
+// count too, like NewRateLimiter. If the cache fails, requests are let
+// through and the error is logged.
+func NewSlidingWindowLimiter(config SlidingWindowConfig) fiber.Handler {
+	return newSlidingWindowLimiter(config, time.Now)
+}
+
+// newSlidingWindowLimiter is NewSlidingWindowLimiter reading the time from now
+func newSlidingWindowLimiter(config SlidingWindowConfig, now func() time.Time) fiber.Handler {
+	maxRequests := config.Max
+	if maxRequests <= 0 {
+		maxRequests = 5
+	}
+
+	window := config.Window
+	if window <= 0 {
+		window = 1 * time.Minute
+	}
+
+	counters := config.Cache
+	if counters == nil {
+		counters = cache.NewLRUCache(DefaultSlidingWindowCacheSize)
+	}
+
+	keyGenerator := config.KeyGenerator
+	if keyGenerator == nil {
+		keyGenerator = ipKey
+	}
+
+	limitReached := config.LimitReached
+	if limitReached == nil {
+		limitReached = tooManyRequests
+	}
+
+	return func(c fiber.Ctx) error {
+		nanos := now().UnixNano()
+		current := nanos / int64(window)
+		// How far into the current window now is, from 0 to 1
+		elapsed := float64(nanos%int64(window)) / float64(window)
+
+		key := "ratelimit:" + keyGenerator(c) + ":"
+		ctx := cache.WithTTLJitter(c.Context(), 0)
+
+		// Each counter must outlive the window after its own
+		count, err := counters.IncrementWithTTL(ctx, key+strconv.FormatInt(current, 10), 1, 2*window)
+		if err != nil {
+			logging.ErrorWithFields("Rate limit counter failed", err,
+				map[string]interface{}{
+					"path": c.Path(),
+				})
+			return c.Next()
+		}
+
+		var previous int64
+		if value, ok := counters.Get(ctx, key+strconv.FormatInt(current-1, 10)); ok {
+			previous = counterValue(value)
+		}
+
+		estimate := float64(previous)*(1-elapsed) + float64(count)
+		c.Set("X-RateLimit-Limit", strconv.Itoa(maxRequests))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(max(maxRequests-int(math.Ceil(estimate)), 0)))
+
+		if estimate > float64(maxRequests) {
+			retryAfter := slidingWindowRetryAfter(previous, count, maxRequests, elapsed, window)
+			c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(max(int64(math.Ceil(retryAfter.Seconds())), 1), 10))
+			return limitReached(c)
+		}
+
+		return c.Next()
+	}
+}
+
+// slidingWindowRetryAfter returns how long until one more request fits under
+// maxRequests, given the previous and current window counts and how far into
+// the current window now is
+func slidingWindowRetryAfter(previous, current int64, maxRequests int, elapsed float64, window time.Duration) time.Duration {
+	room := float64(maxRequests - 1)
+
+	// In windows from the start of the current one
+	var fits float64
+	if float64(current) <= room {
+		// The previous window's weight has to shrink enough
+		fits = 1 - (room-float64(current))/float64(previous)
+	} else {
+		// Only once the current window becomes the previous one
+		fits = 2 - room/float64(current)
+	}
+
+	return time.Duration((fits - elapsed) * float64(window))
+}
+
+// counterValue returns a counter read back with Get: an int64 from the
+// in-memory cache, or a float64 from caches that decode JSON
+func counterValue(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// TestSlidingWindowLimiterBoundaryBurst sends Max requests just before a
+// window boundary and Max more just after it. A fixed window counts the two
+// bursts in separate windows and accepts all of them, twice Max in two
+// seconds; the sliding window still counts the first burst and rejects the
+// second.
+func TestSlidingWindowLimiterBoundaryBurst(t *testing.T) {
+	const maxRequests = 5
+	window := time.Minute
+	boundary := time.Unix(0, 0).Add(1000 * window)
+
+	now := boundary.Add(-time.Second)
+	app := fiber.New()
+	app.Get("/", newSlidingWindowLimiter(SlidingWindowConfig{Max: maxRequests, Window: window}, func() time.Time {
+		return now
+	}), func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	send := func(n int) (accepted int, retryAfter string) {
+		for i := 0; i < n; i++ {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch resp.StatusCode {
+			case fiber.StatusOK:
+				accepted++
+			case fiber.StatusTooManyRequests:
+				retryAfter = resp.Header.Get(fiber.HeaderRetryAfter)
+			default:
+				t.Fatalf("unexpected status %d", resp.StatusCode)
+			}
+		}
+		return accepted, retryAfter
+	}
+
+	if accepted, _ := send(maxRequests); accepted != maxRequests {
+		t.Fatalf("before the boundary: accepted %d, want %d", accepted, maxRequests)
+	}
+
+	// Each burst fits a fixed window of its own
+	if before, after := now.UnixNano()/int64(window), boundary.Add(time.Second).UnixNano()/int64(window); before == after {
+		t.Fatal("bursts fall in the same fixed window")
+	}
+
+	now = boundary.Add(time.Second)
+	accepted, retryAfter := send(maxRequests)
+	if accepted != 0 {
+		t.Fatalf("after the boundary: accepted %d, want 0", accepted)
+	}
+	if retryAfter == "" {
+		t.Fatal("rejected response has no Retry-After header")
+	}
+
+	// Rejected requests count too, so requests fit again once the second
+	// burst has slid halfway out of the window
+	now = boundary.Add(window + window/2)
+	if accepted, _ := send(1); accepted != 1 {
+		t.Fatalf("a window and a half later: accepted %d, want 1", accepted)
+	}
+}
+
+func TestSlidingWindowLimiterKeys(t *testing.T) {
+	now := time.Unix(0, 0).Add(time.Hour)
+	app := fiber.New()
+	app.Get("/", newSlidingWindowLimiter(SlidingWindowConfig{
+		Max: 1,
+		KeyGenerator: func(c fiber.Ctx) string {
+			return c.Get("X-Client")
+		},
+	}, func() time.Time { return now }), func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for _, tt := range []struct {
+		client string
+		want   int
+	}{
+		{"a", fiber.StatusOK},
+		{"a", fiber.StatusTooManyRequests},
+		{"b", fiber.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Client", tt.client)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Fatalf("client %s: status = %d, want %d", tt.client, resp.StatusCode, tt.want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pengenjago/fibox/response"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// NewTimeout creates middleware that sets a deadline d on c.Context() and
+// responds 503 in the standard envelope when the chain returns an error
+// wrapping context.DeadlineExceeded.
+//
+// Passing c.Context() to context-aware calls such as HTTPClient.GetCtx cancels
+// them once the deadline passes, and the error they return becomes the 503.
+// The chain runs on the request goroutine, since fiber.Ctx isn't safe for
+// concurrent use, so a handler that ignores c.Context() is never cut off: its
+// response is sent as is, however late it finishes.
+func NewTimeout(d time.Duration) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		parent := c.Context()
+		ctx, cancel := context.WithTimeout(parent, d)
+		defer cancel()
+
+		c.SetContext(ctx)
+		err := c.Next()
+		c.SetContext(parent)
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			return response.ServiceUnavailable(c, "Request timed out")
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler fiber.Handler
+		status  int
+		body    string
+	}{
+		{"finishes in time", func(c fiber.Ctx) error {
+			return c.SendString("ok")
+		}, fiber.StatusOK, "ok"},
+		{"returns the deadline error", func(c fiber.Ctx) error {
+			<-c.Context().Done()
+			return fmt.Errorf("upstream: %w", c.Context().Err())
+		}, fiber.StatusServiceUnavailable, ""},
+		{"succeeds after the deadline", func(c fiber.Ctx) error {
+			time.Sleep(50 * time.Millisecond)
+			return c.SendString("late")
+		}, fiber.StatusOK, "late"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", NewTimeout(10*time.Millisecond), tt.handler)
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.body != "" {
+				body, _ := io.ReadAll(resp.Body)
+				if string(body) != tt.body {
+					t.Fatalf("body = %q, want %q", body, tt.body)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeoutRestoresContext(t *testing.T) {
+	app := fiber.New()
+	var after context.Context
+	app.Use(func(c fiber.Ctx) error {
+		err := c.Next()
+		after = c.Context()
+		return err
+	})
+	app.Get("/", NewTimeout(time.Second), func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := after.Deadline(); ok {
+		t.Fatalf("context after NewTimeout still has its deadline")
+	}
+}
@@ -0,0 +1,56 @@
+package response
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+)
+
+// ErrInvalidRequest is returned by BindAndValidate after it has sent the
+// 400 or 422 response
+var ErrInvalidRequest = errors.New("invalid request")
+
+var structValidator atomic.Pointer[validator.Validate]
+
+func init() {
+	structValidator.Store(validator.New(validator.WithRequiredStructEnabled()))
+}
+
+// SetValidator replaces the validator used by BindAndValidate, e.g. one with
+// custom validations registered. Passing nil is ignored.
+func SetValidator(v *validator.Validate) {
+	if v != nil {
+		structValidator.Store(v)
+	}
+}
+
+// BindAndValidate parses the request body into out according to its
+// Content-Type and validates it with the configured validator. An
+// unparseable body gets a 400 response and a failed validation a 422 with
+// field errors under data.errors. In both cases ErrInvalidRequest is
+// returned and the handler should return nil: returning the error would let
+// fiber's error handler replace the response that was already sent.
+func BindAndValidate(c fiber.Ctx, out interface{}) error {
+	if err := c.Bind().Body(out); err != nil {
+		if err := BadRequest(c, "Invalid request body"); err != nil {
+			return err
+		}
+		return ErrInvalidRequest
+	}
+
+	if err := structValidator.Load().Struct(out); err != nil {
+		fieldErrors := FieldErrors(err)
+		if fieldErrors == nil {
+			// Not validation failures, e.g. out isn't a struct pointer
+			return err
+		}
+		if err := ValidationError(c, fieldErrors); err != nil {
+			return err
+		}
+		return ErrInvalidRequest
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package response
+
+import (
+	"io"
+	"mime"
+	"path/filepath"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Download streams data as a file attachment named filename. An empty
+// contentType is derived from the file extension, default
+// application/octet-stream. If data implements io.Closer, it is closed once
+// the body has been sent.
+func Download(c fiber.Ctx, filename, contentType string, data io.Reader) error {
+	setFileHeaders(c, "attachment", filename, contentType)
+	return c.SendStream(data)
+}
+
+// DownloadBytes sends data as a file attachment named filename, see Download
+func DownloadBytes(c fiber.Ctx, filename, contentType string, data []byte) error {
+	setFileHeaders(c, "attachment", filename, contentType)
+	return c.Send(data)
+}
+
+// Inline streams data for display in the browser, e.g. a PDF preview, while
+// keeping filename for when it's saved. See Download.
+func Inline(c fiber.Ctx, filename, contentType string, data io.Reader) error {
+	setFileHeaders(c, "inline", filename, contentType)
+	return c.SendStream(data)
+}
+
+// InlineBytes sends data for display in the browser, see Inline
+func InlineBytes(c fiber.Ctx, filename, contentType string, data []byte) error {
+	setFileHeaders(c, "inline", filename, contentType)
+	return c.Send(data)
+}
+
+// setFileHeaders sets the status, Content-Type and Content-Disposition of a
+// file response. Non-ASCII filenames are sent RFC 2231 encoded (filename*).
+func setFileHeaders(c fiber.Ctx, disposition, filename, contentType string) {
+	filename = filepath.Base(filename)
+
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(filename))
+	}
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+
+	header := mime.FormatMediaType(disposition, map[string]string{"filename": filename})
+	if header == "" {
+		header = disposition
+	}
+
+	c.Status(fiber.StatusOK)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, header)
+}
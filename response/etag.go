@@ -0,0 +1,62 @@
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// SuccessWithETag sends a success response with an ETag, or 304 Not Modified
+// when the request's If-None-Match already names it. See RespondWithETag.
+func SuccessWithETag(c fiber.Ctx, message string, data interface{}) error {
+	return RespondWithETag(c, Response{
+		Success: true,
+		Message: message,
+		Data:    data,
+	})
+}
+
+// RespondWithETag sends payload with status 200 and an ETag, or 304 Not
+// Modified for a GET or HEAD whose If-None-Match matches it.
+//
+// The ETag is the first 128 bits of the SHA-256 of the payload's JSON
+// encoding. It is weak (W/"...") because the same payload may be sent as JSON
+// or XML. The whole payload is hashed, so for paginated lists pass the full
+// envelope, Pagination included, to keep pages with equal items apart.
+func RespondWithETag(c fiber.Ctx, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Respond(c, fiber.StatusOK, payload)
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Set(fiber.HeaderETag, etag)
+
+	method := c.Method()
+	if (method == fiber.MethodGet || method == fiber.MethodHead) && etagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		c.Status(fiber.StatusNotModified)
+		return nil
+	}
+
+	return Respond(c, fiber.StatusOK, payload)
+}
+
+// etagMatches reports whether an If-None-Match header names etag, using the
+// weak comparison RFC 9110 requires for If-None-Match
+func etagMatches(header, etag string) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package response
+
+import (
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// Translator resolves a message key for the request, typically by reading
+// its Accept-Language header, e.g. with c.AcceptsLanguages("id", "en")
+type Translator func(c fiber.Ctx, key string) string
+
+var translator atomic.Pointer[Translator]
+
+// SetTranslator sets the translator used by the *Key helpers. Passing nil
+// removes it, so keys are sent as-is.
+func SetTranslator(t Translator) {
+	if t == nil {
+		translator.Store(nil)
+		return
+	}
+	translator.Store(&t)
+}
+
+// Translate resolves key with the configured translator, falling back to the
+// key itself when no translator is set or it returns an empty string
+func Translate(c fiber.Ctx, key string) string {
+	t := translator.Load()
+	if t == nil {
+		return key
+	}
+
+	if message := (*t)(c, key); message != "" {
+		return message
+	}
+	return key
+}
+
+// SuccessKey sends a success response with a translated message
+func SuccessKey(c fiber.Ctx, key string, data interface{}) error {
+	return Success(c, Translate(c, key), data)
+}
+
+// CreatedKey sends a created response with a translated message
+func CreatedKey(c fiber.Ctx, key string, data interface{}) error {
+	return Created(c, Translate(c, key), data)
+}
+
+// BadRequestKey sends a bad request error response with a translated message
+func BadRequestKey(c fiber.Ctx, key string) error {
+	return BadRequest(c, Translate(c, key))
+}
+
+// UnauthorizedKey sends an unauthorized error response with a translated message
+func UnauthorizedKey(c fiber.Ctx, key string) error {
+	return Unauthorized(c, Translate(c, key))
+}
+
+// ForbiddenKey sends a forbidden error response with a translated message
+func ForbiddenKey(c fiber.Ctx, key string) error {
+	return Forbidden(c, Translate(c, key))
+}
+
+// NotFoundKey sends a not found error response with a translated message
+func NotFoundKey(c fiber.Ctx, key string) error {
+	return NotFound(c, Translate(c, key))
+}
+
+// ConflictKey sends a conflict error response with a translated message
+func ConflictKey(c fiber.Ctx, key string) error {
+	return Conflict(c, Translate(c, key))
+}
+
+// TooManyRequestsKey sends a too many requests error response with a translated message
+func TooManyRequestsKey(c fiber.Ctx, key string) error {
+	return TooManyRequests(c, Translate(c, key))
+}
+
+// InternalErrorKey sends an internal server error response with a translated message
+func InternalErrorKey(c fiber.Ctx, key string) error {
+	return InternalError(c, Translate(c, key))
+}
+
+// ErrorWithCodeKey sends an error response with a machine-readable code and a
+// translated message
+func ErrorWithCodeKey(c fiber.Ctx, status int, code, key string) error {
+	return ErrorWithCode(c, status, code, Translate(c, key))
+}
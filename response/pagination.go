@@ -0,0 +1,47 @@
+package response
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// PageRequest holds the requested page number (1-based) and page size
+type PageRequest struct {
+	PageNo   int `json:"pageNo" query:"pageNo"`
+	PageSize int `json:"pageSize" query:"pageSize"`
+}
+
+// Validate reports an error when the page number or size isn't positive
+func (p PageRequest) Validate() error {
+	if p.PageNo < 1 {
+		return fmt.Errorf("pageNo must be greater than 0, got %d", p.PageNo)
+	}
+	if p.PageSize < 1 {
+		return fmt.Errorf("pageSize must be greater than 0, got %d", p.PageSize)
+	}
+	return nil
+}
+
+// Offset returns the number of records to skip for this page
+func (p PageRequest) Offset() int {
+	if p.PageNo < 1 {
+		return 0
+	}
+	return (p.PageNo - 1) * p.PageSize
+}
+
+// Paginated sends a success response with typed items and pagination info.
+// An invalid page request results in a bad request response, and nil items
+// are sent as an empty list.
+func Paginated[T any](c fiber.Ctx, message string, items []T, page PageRequest, total int) error {
+	if err := page.Validate(); err != nil {
+		return BadRequest(c, err.Error())
+	}
+
+	if items == nil {
+		items = []T{}
+	}
+
+	return SuccessWithPagination(c, message, items, page.PageNo, page.PageSize, total)
+}
@@ -0,0 +1,171 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// MIMEApplicationProblemJSON is the RFC 7807 problem details media type
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// ErrorFormat selects the body of error responses for clients that don't
+// ask for a specific one
+type ErrorFormat int32
+
+const (
+	// ErrorFormatEnvelope sends errors in the standard Response envelope
+	ErrorFormatEnvelope ErrorFormat = iota
+	// ErrorFormatProblem sends errors as RFC 7807 problem details
+	ErrorFormatProblem
+)
+
+var errorFormat atomic.Int32
+
+// problemLocalsKey marks requests whose errors use problem details, see
+// UseProblemDetails
+type problemLocalsKey struct{}
+
+// SetErrorFormat sets the default error format, ErrorFormatEnvelope unless
+// changed. Clients still get XML when they prefer application/xml, and
+// problem details when they prefer application/problem+json.
+func SetErrorFormat(format ErrorFormat) {
+	errorFormat.Store(int32(format))
+}
+
+// UseProblemDetails creates middleware that makes the error helpers send
+// problem details for the routes behind it, so an API can migrate one route
+// group at a time. Clients preferring application/xml still get XML.
+func UseProblemDetails() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		c.Locals(problemLocalsKey{}, true)
+		return c.Next()
+	}
+}
+
+// ProblemDetails is an RFC 7807 problem details object. Code, Errors and
+// Data carry what the envelope would; Extensions adds further members, which
+// never override the named ones.
+type ProblemDetails struct {
+	Type       string                 `json:"type"`
+	Title      string                 `json:"title"`
+	Status     int                    `json:"status"`
+	Detail     string                 `json:"detail,omitempty"`
+	Instance   string                 `json:"instance,omitempty"`
+	Code       string                 `json:"code,omitempty"`
+	Errors     map[string]string      `json:"errors,omitempty"`
+	Data       interface{}            `json:"data,omitempty"`
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// problemMembers are the member names of ProblemDetails' own fields
+var problemMembers = map[string]bool{
+	"type": true, "title": true, "status": true, "detail": true,
+	"instance": true, "code": true, "errors": true, "data": true,
+}
+
+// MarshalJSON appends the extension members to the problem object
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	type plain ProblemDetails
+	data, err := json.Marshal(plain(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+
+	extensions := make(map[string]interface{}, len(p.Extensions))
+	for key, value := range p.Extensions {
+		if !problemMembers[key] {
+			extensions[key] = value
+		}
+	}
+	if len(extensions) == 0 {
+		return data, nil
+	}
+
+	extra, err := json.Marshal(extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Join {"type":...} and {"ext":...} into one object
+	data[len(data)-1] = ','
+	return append(data, extra[1:]...), nil
+}
+
+// Problem sends problem details with the given status and detail, whatever
+// the error format
+func Problem(c fiber.Ctx, status int, detail string) error {
+	return SendProblem(c, ProblemDetails{
+		Status:   status,
+		Detail:   detail,
+		Instance: c.Path(),
+	})
+}
+
+// SendProblem sends problem with the problem+json content type. An empty
+// Type defaults to "about:blank", an empty Title to the status text and a
+// zero Status to 500.
+func SendProblem(c fiber.Ctx, problem ProblemDetails) error {
+	if problem.Status == 0 {
+		problem.Status = fiber.StatusInternalServerError
+	}
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+
+	return c.Status(problem.Status).JSON(problem, MIMEApplicationProblemJSON)
+}
+
+// ToProblem maps an error envelope to problem details: Message becomes
+// Detail, Code is kept, and ValidationErrors data becomes Errors
+func ToProblem(status int, resp Response) ProblemDetails {
+	problem := ProblemDetails{
+		Status: status,
+		Detail: resp.Message,
+		Code:   resp.Code,
+	}
+	if validationErrors, ok := resp.Data.(ValidationErrors); ok {
+		problem.Errors = validationErrors.Errors
+	} else {
+		problem.Data = resp.Data
+	}
+	return problem
+}
+
+// respondError sends an error response in the negotiated format
+func respondError(c fiber.Ctx, status int, code, message string, data interface{}) error {
+	resp := Response{
+		Success: false,
+		Code:    code,
+		Message: message,
+		Data:    data,
+	}
+
+	if !wantsProblem(c) {
+		return Respond(c, status, resp)
+	}
+
+	problem := ToProblem(status, resp)
+	problem.Instance = c.Path()
+	return SendProblem(c, problem)
+}
+
+// wantsProblem reports whether an error response should use problem details
+func wantsProblem(c fiber.Ctx) bool {
+	switch c.Accepts(fiber.MIMEApplicationJSON, fiber.MIMEApplicationXML, MIMEApplicationProblemJSON) {
+	case MIMEApplicationProblemJSON:
+		return true
+	case fiber.MIMEApplicationXML:
+		return false
+	default:
+		if enabled, ok := c.Locals(problemLocalsKey{}).(bool); ok && enabled {
+			return true
+		}
+		return ErrorFormat(errorFormat.Load()) == ErrorFormatProblem
+	}
+}
@@ -1,31 +1,62 @@
 package response
 
-import "github.com/gofiber/fiber/v3"
+import (
+	"encoding/xml"
+
+	"github.com/gofiber/fiber/v3"
+)
 
 // Response is the standard API response structure
 type Response struct {
-	Success    bool        `json:"success"`
-	Message    string      `json:"message,omitempty"`
-	Data       interface{} `json:"data,omitempty"`
-	Pagination Pagination  `json:"pagination,omitempty"`
+	XMLName    xml.Name    `json:"-" xml:"response"`
+	Success    bool        `json:"success" xml:"success"`
+	Code       string      `json:"code,omitempty" xml:"code,omitempty"`
+	Message    string      `json:"message,omitempty" xml:"message,omitempty"`
+	Data       interface{} `json:"data,omitempty" xml:"data,omitempty"`
+	Pagination Pagination  `json:"pagination,omitempty" xml:"pagination,omitempty"`
 }
 
 type Pagination struct {
-	PageNo      int `json:"pageNo"`
-	PageSize    int `json:"pageSize"`
-	PageTotal   int `json:"pageTotal"`
-	TotalRecord int `json:"totalRecord"`
+	PageNo      int `json:"pageNo" xml:"pageNo"`
+	PageSize    int `json:"pageSize" xml:"pageSize"`
+	PageTotal   int `json:"pageTotal" xml:"pageTotal"`
+	TotalRecord int `json:"totalRecord" xml:"totalRecord"`
 }
 
-// Success sends a success response
-func Success(c fiber.Ctx, message string, data interface{}) error {
-	return c.Status(fiber.StatusOK).JSON(Response{
-		Success: true,
+// Respond sends payload with the given status, encoded as XML when the client
+// prefers application/xml and as JSON otherwise.
+// Payloads that can't be encoded as XML (e.g. maps) fall back to JSON.
+func Respond(c fiber.Ctx, status int, payload interface{}) error {
+	c.Status(status)
+
+	if c.Accepts(fiber.MIMEApplicationJSON, fiber.MIMEApplicationXML) == fiber.MIMEApplicationXML {
+		if err := c.XML(payload); err == nil {
+			return nil
+		}
+	}
+
+	return c.JSON(payload)
+}
+
+// JSON sends the standard envelope with any status code. Unsuccessful 4xx and
+// 5xx responses follow the error format negotiation, see SetErrorFormat.
+func JSON(c fiber.Ctx, status int, success bool, message string, data interface{}) error {
+	if !success && status >= fiber.StatusBadRequest {
+		return respondError(c, status, "", message, data)
+	}
+
+	return Respond(c, status, Response{
+		Success: success,
 		Message: message,
 		Data:    data,
 	})
 }
 
+// Success sends a success response
+func Success(c fiber.Ctx, message string, data interface{}) error {
+	return JSON(c, fiber.StatusOK, true, message, data)
+}
+
 // SuccessWithPagination sends a success response with pagination info
 func SuccessWithPagination(c fiber.Ctx, message string, data interface{}, pageNo, pageSize, totalRecord int) error {
 	totalPage := 0
@@ -37,67 +68,113 @@ func SuccessWithPagination(c fiber.Ctx, message string, data interface{}, pageNo
 		}
 	}
 
-	if pageNo > totalPage {
+	// Clamp to the last page, but keep at least page 1 for empty result sets
+	if totalPage > 0 && pageNo > totalPage {
 		pageNo = totalPage
 	}
+	if pageNo < 1 {
+		pageNo = 1
+	}
 
-	return c.Status(fiber.StatusOK).JSON(Response{
+	return Respond(c, fiber.StatusOK, Response{
 		Success: true,
 		Message: message,
 		Data:    data,
 		Pagination: Pagination{
-			PageNo:    pageNo,
-			PageSize:  pageSize,
-			PageTotal: totalPage,
+			PageNo:      pageNo,
+			PageSize:    pageSize,
+			PageTotal:   totalPage,
+			TotalRecord: totalRecord,
 		},
 	})
 }
 
 // Created sends a created response
 func Created(c fiber.Ctx, message string, data interface{}) error {
-	return c.Status(fiber.StatusCreated).JSON(Response{
-		Success: true,
-		Message: message,
-		Data:    data,
-	})
+	return JSON(c, fiber.StatusCreated, true, message, data)
+}
+
+// Accepted sends an accepted response for work that will complete asynchronously
+func Accepted(c fiber.Ctx, message string, data interface{}) error {
+	return JSON(c, fiber.StatusAccepted, true, message, data)
 }
 
 // BadRequest sends a bad request error response
 func BadRequest(c fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusBadRequest).JSON(Response{
-		Success: false,
-		Message: message,
-	})
+	return JSON(c, fiber.StatusBadRequest, false, message, nil)
 }
 
 // Unauthorized sends an unauthorized error response
 func Unauthorized(c fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusUnauthorized).JSON(Response{
-		Success: false,
-		Message: message,
-	})
+	return JSON(c, fiber.StatusUnauthorized, false, message, nil)
 }
 
 // Forbidden sends a forbidden error response
 func Forbidden(c fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusForbidden).JSON(Response{
-		Success: false,
-		Message: message,
-	})
+	return JSON(c, fiber.StatusForbidden, false, message, nil)
 }
 
 // NotFound sends a not found error response
 func NotFound(c fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusNotFound).JSON(Response{
-		Success: false,
-		Message: message,
-	})
+	return JSON(c, fiber.StatusNotFound, false, message, nil)
+}
+
+// Conflict sends a conflict error response
+func Conflict(c fiber.Ctx, message string) error {
+	return JSON(c, fiber.StatusConflict, false, message, nil)
+}
+
+// PayloadTooLarge sends a payload too large error response
+func PayloadTooLarge(c fiber.Ctx, message string) error {
+	return JSON(c, fiber.StatusRequestEntityTooLarge, false, message, nil)
+}
+
+// TooManyRequests sends a too many requests error response
+func TooManyRequests(c fiber.Ctx, message string) error {
+	return JSON(c, fiber.StatusTooManyRequests, false, message, nil)
 }
 
 // InternalError sends an internal server error response
 func InternalError(c fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusInternalServerError).JSON(Response{
-		Message: message,
-		Success: false,
-	})
+	return JSON(c, fiber.StatusInternalServerError, false, message, nil)
+}
+
+// ServiceUnavailable sends a service unavailable error response
+func ServiceUnavailable(c fiber.Ctx, message string) error {
+	return JSON(c, fiber.StatusServiceUnavailable, false, message, nil)
+}
+
+// GatewayTimeout sends a gateway timeout error response
+func GatewayTimeout(c fiber.Ctx, message string) error {
+	return JSON(c, fiber.StatusGatewayTimeout, false, message, nil)
+}
+
+// ErrorWithCode sends an error response carrying a machine-readable code
+func ErrorWithCode(c fiber.Ctx, status int, code, message string) error {
+	return respondError(c, status, code, message, nil)
+}
+
+// BadRequestWithCode sends a bad request error response with a machine-readable code
+func BadRequestWithCode(c fiber.Ctx, code, message string) error {
+	return ErrorWithCode(c, fiber.StatusBadRequest, code, message)
+}
+
+// UnauthorizedWithCode sends an unauthorized error response with a machine-readable code
+func UnauthorizedWithCode(c fiber.Ctx, code, message string) error {
+	return ErrorWithCode(c, fiber.StatusUnauthorized, code, message)
+}
+
+// ForbiddenWithCode sends a forbidden error response with a machine-readable code
+func ForbiddenWithCode(c fiber.Ctx, code, message string) error {
+	return ErrorWithCode(c, fiber.StatusForbidden, code, message)
+}
+
+// NotFoundWithCode sends a not found error response with a machine-readable code
+func NotFoundWithCode(c fiber.Ctx, code, message string) error {
+	return ErrorWithCode(c, fiber.StatusNotFound, code, message)
+}
+
+// InternalErrorWithCode sends an internal server error response with a machine-readable code
+func InternalErrorWithCode(c fiber.Ctx, code, message string) error {
+	return ErrorWithCode(c, fiber.StatusInternalServerError, code, message)
 }
@@ -0,0 +1,175 @@
+package response
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pengenjago/fibox/logging"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// MIMETextEventStream is the content type of server-sent events
+const MIMETextEventStream = "text/event-stream"
+
+// DefaultSSEHeartbeat is how often StreamSSE writes a heartbeat comment
+const DefaultSSEHeartbeat = 15 * time.Second
+
+// SSEvent is one server-sent event. Data is sent as is when it is a string
+// or []byte and as JSON otherwise; multi-line data is split across data lines.
+type SSEvent struct {
+	ID    string
+	Event string
+	Data  interface{}
+	// Retry tells the client how long to wait before reconnecting
+	Retry time.Duration
+}
+
+// SSEConfig holds server-sent events stream configuration
+type SSEConfig struct {
+	// Heartbeat is how often a comment is written to keep idle connections
+	// open through proxies and to detect disconnected clients, default
+	// DefaultSSEHeartbeat
+	Heartbeat time.Duration
+}
+
+// sseContextKey and sseCancelKey hold the context returned by SSEContext
+type sseContextKey struct{}
+type sseCancelKey struct{}
+
+// sseLineBreaks removes line breaks, which would end an id or event field early
+var sseLineBreaks = strings.NewReplacer("\r\n", "", "\r", "", "\n", "")
+
+// SSEContext returns a context, carrying c.Context()'s values, that is
+// cancelled once the stream started by StreamSSE ends, e.g. when the client
+// disconnects. Pass it to the goroutine producing events so it stops sending.
+// Call it before StreamSSE.
+func SSEContext(c fiber.Ctx) context.Context {
+	if ctx, ok := c.Locals(sseContextKey{}).(context.Context); ok {
+		return ctx
+	}
+
+	// The stream outlives the handler, and with it any deadline middleware
+	ctx, cancel := context.WithCancel(context.WithoutCancel(c.Context()))
+	c.Locals(sseContextKey{}, ctx)
+	c.Locals(sseCancelKey{}, cancel)
+	return ctx
+}
+
+// StreamSSE streams events to the client as server-sent events until events
+// is closed or the client disconnects, with the default configuration
+func StreamSSE(c fiber.Ctx, events <-chan SSEvent) error {
+	return StreamSSEWithConfig(c, events, SSEConfig{})
+}
+
+// StreamSSEWithConfig streams events to the client as server-sent events
+// with status 200, flushing each one, until events is closed or the client
+// disconnects. A disconnect is noticed on the next write, at the latest the
+// next heartbeat, and cancels the SSEContext; a producer not watching it must
+// close events itself or it blocks forever. Like StreamNDJSON, events are
+// written after the handler returns, so an event that can't be encoded is
+// logged and ends the stream.
+func StreamSSEWithConfig(c fiber.Ctx, events <-chan SSEvent, config SSEConfig) error {
+	heartbeat := config.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = DefaultSSEHeartbeat
+	}
+
+	cancel, _ := c.Locals(sseCancelKey{}).(context.CancelFunc)
+	// The context is recycled before the stream runs, so copy what's logged
+	path := strings.Clone(c.Path())
+
+	c.Status(fiber.StatusOK)
+	c.Set(fiber.HeaderContentType, MIMETextEventStream)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	// Stop nginx from buffering the stream
+	c.Set("X-Accel-Buffering", "no")
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		if cancel != nil {
+			defer cancel()
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		// Send the headers right away so the client knows the stream is open
+		if _, err := w.WriteString(": connected\n\n"); err != nil || w.Flush() != nil {
+			return
+		}
+
+		count := 0
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				if err := writeSSEvent(w, event); err != nil {
+					logging.ErrorWithFields("SSE event encode failed", err,
+						map[string]interface{}{
+							"path":   path,
+							"events": count,
+						})
+					return
+				}
+				count++
+			case <-ticker.C:
+				_, _ = w.WriteString(": heartbeat\n\n")
+			}
+
+			// Flushing fails once the client has gone
+			if err := w.Flush(); err != nil {
+				logging.DebugWithFields("SSE client disconnected",
+					map[string]interface{}{
+						"path":   path,
+						"events": count,
+					})
+				return
+			}
+		}
+	})
+}
+
+// writeSSEvent writes event as an SSE frame
+func writeSSEvent(w *bufio.Writer, event SSEvent) error {
+	var data string
+	switch value := event.Data.(type) {
+	case nil:
+	case string:
+		data = value
+	case []byte:
+		data = string(value)
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		data = string(encoded)
+	}
+
+	var frame strings.Builder
+	if event.ID != "" {
+		frame.WriteString("id: " + sseLineBreaks.Replace(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		frame.WriteString("event: " + sseLineBreaks.Replace(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		frame.WriteString("retry: " + strconv.FormatInt(event.Retry.Milliseconds(), 10) + "\n")
+	}
+	if event.Data != nil {
+		data = strings.ReplaceAll(data, "\r\n", "\n")
+		for _, line := range strings.Split(data, "\n") {
+			frame.WriteString("data: " + line + "\n")
+		}
+	}
+	frame.WriteString("\n")
+
+	_, err := w.WriteString(frame.String())
+	return err
+}
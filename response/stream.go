@@ -0,0 +1,59 @@
+package response
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+
+	"github.com/pengenjago/fibox/logging"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// MIMEApplicationNDJSON is the content type of newline-delimited JSON
+const MIMEApplicationNDJSON = "application/x-ndjson"
+
+// StreamNDJSON streams items as newline-delimited JSON with status 200,
+// writing and flushing one line per item until the channel is closed, so the
+// whole collection is never held in memory. The producer must close items.
+//
+// Items are written after the handler returns, once the status has been sent,
+// so a failure mid-stream (an item that can't be encoded or a disconnected
+// client) is logged and ends the response early. The remaining items are then
+// drained so the producer isn't blocked forever.
+func StreamNDJSON(c fiber.Ctx, items <-chan interface{}) error {
+	// The context is recycled before the stream runs, so copy what's logged
+	path := strings.Clone(c.Path())
+
+	c.Status(fiber.StatusOK)
+	c.Set(fiber.HeaderContentType, MIMEApplicationNDJSON)
+
+	return c.SendStreamWriter(func(w *bufio.Writer) {
+		defer func() {
+			for range items {
+			}
+		}()
+
+		encoder := json.NewEncoder(w)
+		count := 0
+		for item := range items {
+			if err := encoder.Encode(item); err != nil {
+				logging.ErrorWithFields("NDJSON stream item encode failed", err,
+					map[string]interface{}{
+						"path":  path,
+						"items": count,
+					})
+				return
+			}
+			if err := w.Flush(); err != nil {
+				logging.ErrorWithFields("NDJSON stream interrupted", err,
+					map[string]interface{}{
+						"path":  path,
+						"items": count,
+					})
+				return
+			}
+			count++
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v3"
+)
+
+// ValidationErrors is the Data payload of a validation error response
+type ValidationErrors struct {
+	Errors map[string]string `json:"errors"`
+}
+
+// UnprocessableEntity sends a 422 response with field-level error details
+// under data.errors, or errors in problem details
+func UnprocessableEntity(c fiber.Ctx, message string, fieldErrors map[string]string) error {
+	return respondError(c, fiber.StatusUnprocessableEntity, "", message, ValidationErrors{
+		Errors: fieldErrors,
+	})
+}
+
+// ValidationError sends a 422 response with field-level error details and a default message
+func ValidationError(c fiber.Ctx, fieldErrors map[string]string) error {
+	return UnprocessableEntity(c, "Validation failed", fieldErrors)
+}
+
+// FieldErrors maps go-playground/validator errors to field -> message pairs.
+// It returns nil when err doesn't contain validator.ValidationErrors.
+func FieldErrors(err error) map[string]string {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	fieldErrors := make(map[string]string, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		fieldErrors[fieldError.Field()] = fieldErrorMessage(fieldError)
+	}
+	return fieldErrors
+}
+
+// fieldErrorMessage builds a readable message for common validation tags
+func fieldErrorMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return fmt.Sprintf("must be at least %s", fieldError.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fieldError.Param())
+	case "len":
+		return fmt.Sprintf("must be exactly %s", fieldError.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fieldError.Param())
+	default:
+		return fmt.Sprintf("failed on the '%s' validation", fieldError.Tag())
+	}
+}
@@ -0,0 +1,144 @@
+// Package tracing adds OpenTelemetry tracing to HTTPClient and fiber apps.
+// It is kept apart from the client and middleware packages so only
+// applications that import it depend on OpenTelemetry. Spans are created with
+// the global TracerProvider and propagated with the global propagator, so
+// configure both with otel.SetTracerProvider and otel.SetTextMapPropagator
+// (e.g. propagation.TraceContext{} for W3C traceparent headers).
+package tracing
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/pengenjago/fibox/client"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies spans created by this package
+const tracerName = "github.com/pengenjago/fibox/tracing"
+
+// parentKey holds the context a request was made with, so each retry
+// attempt's span is a sibling rather than a child of the previous attempt
+type parentKey struct{}
+
+// InstrumentClient makes c start a client span for every request attempt,
+// named by method and templated path (see client.TemplatePath), and inject
+// the trace context into the request headers. Pass the incoming request's
+// context, e.g. c.Context() behind NewTracing, to the Ctx methods to link
+// the spans to the server span.
+func InstrumentClient(c *client.HTTPClient) {
+	c.OnBeforeRequest(func(req *resty.Request) {
+		parent := req.Context()
+		if p, ok := parent.Value(parentKey{}).(context.Context); ok {
+			// A retry: the previous attempt's span is still open if it
+			// failed without a response, since the error hook only runs
+			// after the last attempt
+			if previous := trace.SpanFromContext(parent); previous.IsRecording() {
+				previous.SetStatus(codes.Error, "attempt failed without a response")
+				previous.End()
+			}
+			parent = p
+		}
+
+		path := client.TemplatePath(client.RequestPath(req))
+		// The span is ended by the after-response or error hook, or by the
+		// next attempt
+		ctx, _ := otel.Tracer(tracerName).Start(parent, req.Method+" "+path,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("http.request.method", req.Method),
+				attribute.String("url.template", path),
+			),
+		)
+
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+		req.SetContext(context.WithValue(ctx, parentKey{}, parent))
+	})
+
+	c.OnAfterResponse(func(resp *resty.Response) {
+		span := trace.SpanFromContext(resp.Request.Context())
+		span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode()))
+		if resp.StatusCode() >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, resp.Status())
+		}
+		span.End()
+	})
+
+	c.OnError(func(req *resty.Request, err error) {
+		span := trace.SpanFromContext(req.Context())
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+	})
+}
+
+// NewTracing creates middleware that continues the trace from the incoming
+// request headers and starts a server span for the request. The span is
+// stored in c.Context(), and named by method and route pattern once the
+// route has been matched, e.g. "GET /users/:id".
+func NewTracing() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.Context(), headerCarrier{c})
+		ctx, span := otel.Tracer(tracerName).Start(ctx, c.Method()+" "+c.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Method()),
+				attribute.String("url.path", c.Path()),
+			),
+		)
+		defer span.End()
+
+		c.SetContext(ctx)
+		err := c.Next()
+
+		route := c.Route().Path
+		span.SetName(c.Method() + " " + route)
+		span.SetAttributes(attribute.String("http.route", route))
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			span.RecordError(err)
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, strconv.Itoa(status))
+		}
+
+		return err
+	}
+}
+
+// headerCarrier adapts fiber request headers to propagation.TextMapCarrier
+type headerCarrier struct {
+	c fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	headers := h.c.GetReqHeaders()
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	return keys
+}
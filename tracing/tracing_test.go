@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pengenjago/fibox/client"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// TestInstrumentClientEndsRetriedAttempts drops the connection on the first
+// attempts, so they fail without a response, and checks that every attempt's
+// span is ended
+func TestInstrumentClientEndsRetriedAttempts(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+	otel.SetTracerProvider(provider)
+
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	c := client.NewHTTPClient(client.HTTPClientConfig{
+		BaseURL:          srv.URL,
+		RetryCount:       2,
+		RetryWaitTime:    time.Millisecond,
+		RetryMaxWaitTime: time.Millisecond,
+	})
+	InstrumentClient(c)
+
+	if _, err := c.GetRawCtx(context.Background(), "/items", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if started, ended := len(recorder.Started()), len(recorder.Ended()); started != 3 || ended != 3 {
+		t.Fatalf("started %d spans and ended %d, want 3 and 3", started, ended)
+	}
+	for i, span := range recorder.Ended() {
+		if want := codes.Error; i < 2 && span.Status().Code != want {
+			t.Errorf("span %d status = %v, want %v", i, span.Status().Code, want)
+		}
+	}
+}
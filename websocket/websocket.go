@@ -0,0 +1,224 @@
+// Package websocket serves authenticated WebSocket routes on fiber, built on
+// github.com/fasthttp/websocket. It is kept apart from the middleware package
+// so only applications that import it depend on the WebSocket library.
+// Connections are authenticated with middleware.NewAuth and may be rate
+// limited before the upgrade; afterwards the server keeps them alive with
+// pings and closes them cleanly when the handler returns.
+package websocket
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pengenjago/fibox/jwt"
+	"github.com/pengenjago/fibox/logging"
+	"github.com/pengenjago/fibox/middleware"
+	"github.com/pengenjago/fibox/response"
+
+	fastws "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v3"
+	"github.com/valyala/fasthttp"
+)
+
+// Message types and close codes, re-exported so handlers don't need to
+// import the underlying library
+const (
+	TextMessage   = fastws.TextMessage
+	BinaryMessage = fastws.BinaryMessage
+
+	CloseNormalClosure   = fastws.CloseNormalClosure
+	CloseGoingAway       = fastws.CloseGoingAway
+	ClosePolicyViolation = fastws.ClosePolicyViolation
+)
+
+// TokenQueryParam is the query parameter read for the bearer token when the
+// Authorization header is absent, since browsers can't set headers on
+// WebSocket requests. Tokens in URLs can end up in proxy logs, so keep them
+// short-lived.
+const TokenQueryParam = "access_token"
+
+const (
+	// DefaultPingInterval is how often the server pings an idle connection
+	DefaultPingInterval = 30 * time.Second
+	// DefaultPongTimeout is how long the server waits for a pong before
+	// dropping the connection
+	DefaultPongTimeout = 60 * time.Second
+	// DefaultWriteTimeout bounds control frame writes
+	DefaultWriteTimeout = 10 * time.Second
+	// DefaultReadLimit is the maximum accepted message size in bytes
+	DefaultReadLimit = 1 << 20
+)
+
+// IsCloseError reports whether err is a close message with one of the codes
+var IsCloseError = fastws.IsCloseError
+
+// IsUnexpectedCloseError reports whether err is a close message with a code
+// not in the list
+var IsUnexpectedCloseError = fastws.IsUnexpectedCloseError
+
+// Config holds WebSocket route configuration
+type Config struct {
+	// JWT validates the bearer token before the upgrade; used by Register
+	JWT *jwt.JWTService
+	// RateLimiter runs after authentication and before the upgrade, e.g.
+	// RateLimiters.Chat or NewUserRateLimiter, so it counts connection
+	// attempts per user
+	RateLimiter fiber.Handler
+	// CheckOrigin accepts or rejects the request Origin. Default accepts
+	// requests without an Origin header or whose origin host equals Host.
+	CheckOrigin func(c fiber.Ctx) bool
+	// Subprotocols are negotiated in order of preference
+	Subprotocols []string
+	// PingInterval is how often pings are sent, default DefaultPingInterval
+	PingInterval time.Duration
+	// PongTimeout drops a connection whose pongs stop arriving for this long,
+	// default DefaultPongTimeout. It must exceed PingInterval. Pongs and close
+	// frames are only processed while the handler reads from the connection.
+	PongTimeout time.Duration
+	// WriteTimeout bounds ping and close frame writes, default DefaultWriteTimeout
+	WriteTimeout time.Duration
+	// ReadLimit is the maximum message size in bytes, default DefaultReadLimit;
+	// larger messages close the connection
+	ReadLimit int64
+}
+
+// Conn is an upgraded connection. Reads and writes follow the underlying
+// library's rules: one concurrent reader and one concurrent writer.
+type Conn struct {
+	*fastws.Conn
+	ctx context.Context
+}
+
+// Context carries the request context values, such as the request ID and
+// logger fields, and is cancelled once the handler returns
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// Handler serves one connection. The connection is closed with a normal
+// closure when it returns.
+type Handler func(conn *Conn, auth middleware.AuthInfo)
+
+// Register mounts a WebSocket route at path: the token is read from the
+// Authorization header or the access_token query parameter and validated
+// with config.JWT, config.RateLimiter is applied, then the connection is
+// upgraded and passed to handler
+func Register(router fiber.Router, path string, config Config, handler Handler) {
+	var handlers []any
+	if config.RateLimiter != nil {
+		handlers = append(handlers, config.RateLimiter)
+	}
+	handlers = append(handlers, New(config, handler))
+
+	auth := middleware.NewAuth(middleware.AuthConfig{
+		JWT:        config.JWT,
+		QueryParam: TokenQueryParam,
+	})
+	router.Get(path, auth, handlers...)
+}
+
+// New creates the handler that upgrades the request and serves it with
+// handler. It must run after middleware.NewAuth; unauthenticated
+// requests get 401 and non-WebSocket requests 426.
+func New(config Config, handler Handler) fiber.Handler {
+	if config.PingInterval <= 0 {
+		config.PingInterval = DefaultPingInterval
+	}
+	if config.PongTimeout <= 0 {
+		config.PongTimeout = DefaultPongTimeout
+	}
+	if config.WriteTimeout <= 0 {
+		config.WriteTimeout = DefaultWriteTimeout
+	}
+	if config.ReadLimit <= 0 {
+		config.ReadLimit = DefaultReadLimit
+	}
+
+	upgrader := fastws.FastHTTPUpgrader{
+		Subprotocols: config.Subprotocols,
+	}
+	if config.CheckOrigin != nil {
+		// Checked before the upgrade, where the fiber.Ctx is available
+		upgrader.CheckOrigin = func(*fasthttp.RequestCtx) bool { return true }
+	}
+
+	return func(c fiber.Ctx) error {
+		if !fastws.FastHTTPIsWebSocketUpgrade(c.RequestCtx()) {
+			return response.JSON(c, fiber.StatusUpgradeRequired, false, "WebSocket upgrade required", nil)
+		}
+
+		auth := middleware.GetAuthInfo(c)
+		if auth.UserID == "" {
+			return response.Unauthorized(c, "Authentication required")
+		}
+
+		if config.CheckOrigin != nil && !config.CheckOrigin(c) {
+			return response.Forbidden(c, "Origin not allowed")
+		}
+
+		// fiber.Ctx is released once this handler returns, so keep only the
+		// context values for the connection
+		parent := context.WithoutCancel(c.Context())
+
+		err := upgrader.Upgrade(c.RequestCtx(), func(ws *fastws.Conn) {
+			ctx, cancel := context.WithCancel(parent)
+			defer cancel()
+
+			serve(&Conn{Conn: ws, ctx: ctx}, auth, config, handler)
+		})
+		if err != nil {
+			var handshakeErr fastws.HandshakeError
+			if errors.As(err, &handshakeErr) {
+				// The upgrader has already written the error response
+				return nil
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// serve runs handler with keepalive pings and closes the connection cleanly
+// once it returns
+func serve(conn *Conn, auth middleware.AuthInfo, config Config, handler Handler) {
+	conn.SetReadLimit(config.ReadLimit)
+	_ = conn.SetReadDeadline(time.Now().Add(config.PongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(config.PongTimeout))
+	})
+
+	done := make(chan struct{})
+	defer close(done)
+	go keepAlive(conn, config, done)
+
+	logging.DebugCtx(conn.ctx, "WebSocket connection opened")
+
+	handler(conn, auth)
+
+	// The peer may already be gone, so a failed close frame is expected
+	message := fastws.FormatCloseMessage(CloseNormalClosure, "")
+	_ = conn.WriteControl(fastws.CloseMessage, message, time.Now().Add(config.WriteTimeout))
+	_ = conn.Close()
+
+	logging.DebugCtx(conn.ctx, "WebSocket connection closed")
+}
+
+// keepAlive pings the peer every PingInterval until done is closed or a ping
+// fails
+func keepAlive(conn *Conn, config Config, done <-chan struct{}) {
+	ticker := time.NewTicker(config.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			// WriteControl may be called concurrently with the handler's writes
+			if err := conn.WriteControl(fastws.PingMessage, nil, time.Now().Add(config.WriteTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}